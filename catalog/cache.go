@@ -0,0 +1,131 @@
+// Package catalog provides an informer backed cache of the products for validations across products
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+	"github.com/arutselvan15/estore-product-kube-client/pkg/client/clientset/versioned"
+	informers "github.com/arutselvan15/estore-product-kube-client/pkg/client/informers/externalversions"
+	listers "github.com/arutselvan15/estore-product-kube-client/pkg/client/listers/estore/v1"
+)
+
+const (
+	// BrandIndex index of the products by lower case brand
+	BrandIndex = "brand"
+	// LabelIndex index of the products by label key=value
+	LabelIndex = "label"
+)
+
+// Cache products of all namespaces kept in sync by a shared informer
+type Cache struct {
+	factory  informers.SharedInformerFactory
+	informer cache.SharedIndexInformer
+	lister   listers.ProductLister
+}
+
+// New cache with the namespace, brand and label indexers, a resync of 0 disables the periodic resync
+func New(client versioned.Interface, resync time.Duration) (*Cache, error) {
+	factory := informers.NewSharedInformerFactory(client, resync)
+	products := factory.Estore().V1().Products()
+
+	// the namespace index is added by the informer factory
+	informer := products.Informer()
+	if err := informer.AddIndexers(cache.Indexers{BrandIndex: brandIndexFunc, LabelIndex: labelIndexFunc}); err != nil {
+		return nil, fmt.Errorf("unable to add product cache indexers: %v", err)
+	}
+
+	return &Cache{factory: factory, informer: informer, lister: products.Lister()}, nil
+}
+
+// Start run the informer until the stop channel is closed
+func (c *Cache) Start(stopCh <-chan struct{}) {
+	c.factory.Start(stopCh)
+}
+
+// WaitForSync block until the initial list is cached, false when the stop channel is closed first
+func (c *Cache) WaitForSync(stopCh <-chan struct{}) bool {
+	return cache.WaitForCacheSync(stopCh, c.informer.HasSynced)
+}
+
+// HasSynced check the initial list is cached
+func (c *Cache) HasSynced() bool {
+	return c.informer.HasSynced()
+}
+
+// List products of the namespace, all namespaces for metav1.NamespaceAll
+func (c *Cache) List(namespace string) ([]*pdtv1.Product, error) {
+	if !c.HasSynced() {
+		return nil, fmt.Errorf("product cache not synced")
+	}
+
+	if namespace == metav1.NamespaceAll {
+		return c.lister.List(labels.Everything())
+	}
+
+	return c.lister.Products(namespace).List(labels.Everything())
+}
+
+// ListByBrand products of the brand in all namespaces, case insensitive
+func (c *Cache) ListByBrand(brand string) ([]*pdtv1.Product, error) {
+	return c.byIndex(BrandIndex, strings.ToLower(brand))
+}
+
+// ListByLabel products with the label value in all namespaces
+func (c *Cache) ListByLabel(key, value string) ([]*pdtv1.Product, error) {
+	return c.byIndex(LabelIndex, labelIndexValue(key, value))
+}
+
+func (c *Cache) byIndex(index, value string) ([]*pdtv1.Product, error) {
+	if !c.HasSynced() {
+		return nil, fmt.Errorf("product cache not synced")
+	}
+
+	objects, err := c.informer.GetIndexer().ByIndex(index, value)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*pdtv1.Product, 0, len(objects))
+
+	for _, obj := range objects {
+		if pdt, ok := obj.(*pdtv1.Product); ok {
+			products = append(products, pdt)
+		}
+	}
+
+	return products, nil
+}
+
+func brandIndexFunc(obj interface{}) ([]string, error) {
+	pdt, ok := obj.(*pdtv1.Product)
+	if !ok || pdt.Spec.Brand == "" {
+		return nil, nil
+	}
+
+	return []string{strings.ToLower(pdt.Spec.Brand)}, nil
+}
+
+func labelIndexFunc(obj interface{}) ([]string, error) {
+	pdt, ok := obj.(*pdtv1.Product)
+	if !ok {
+		return nil, nil
+	}
+
+	values := make([]string, 0, len(pdt.Labels))
+	for k, v := range pdt.Labels {
+		values = append(values, labelIndexValue(k, v))
+	}
+
+	return values, nil
+}
+
+func labelIndexValue(key, value string) string {
+	return key + "=" + value
+}
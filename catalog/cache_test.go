@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+	pdtFake "github.com/arutselvan15/estore-product-kube-client/pkg/client/clientset/versioned/fake"
+)
+
+func product(namespace, name, brand string, labels map[string]string) *pdtv1.Product {
+	return &pdtv1.Product{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       pdtv1.ProductSpec{Brand: brand},
+	}
+}
+
+func names(products []*pdtv1.Product) []string {
+	var result []string
+	for _, pdt := range products {
+		result = append(result, pdt.Namespace+"/"+pdt.Name)
+	}
+
+	return result
+}
+
+func TestCache(t *testing.T) {
+	client := pdtFake.NewSimpleClientset(
+		product("store-a", "iphone", "Apple", map[string]string{"sku": "A2111"}),
+		product("store-a", "pixel", "google", nil),
+		product("store-b", "iphone", "apple", map[string]string{"sku": "A2111", "tier": "flagship"}),
+	)
+
+	c, err := New(client, 0)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, err = c.List(metav1.NamespaceAll)
+	assert.Error(t, err, "not synced cache must not be read")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	c.Start(stopCh)
+	assert.True(t, c.WaitForSync(stopCh))
+
+	all, err := c.List(metav1.NamespaceAll)
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	namespaced, _ := c.List("store-a")
+	assert.ElementsMatch(t, []string{"store-a/iphone", "store-a/pixel"}, names(namespaced))
+
+	byBrand, _ := c.ListByBrand("APPLE")
+	assert.ElementsMatch(t, []string{"store-a/iphone", "store-b/iphone"}, names(byBrand))
+
+	byLabel, _ := c.ListByLabel("tier", "flagship")
+	assert.Equal(t, []string{"store-b/iphone"}, names(byLabel))
+
+	// changes are picked up by the informer
+	_, _ = client.EstoreV1().Products("store-c").Create(product("store-c", "galaxy", "samsung", nil))
+
+	assert.Eventually(t, func() bool {
+		products, _ := c.ListByBrand("samsung")
+		return len(products) == 1
+	}, 5*time.Second, 50*time.Millisecond)
+}
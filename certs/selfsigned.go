@@ -0,0 +1,214 @@
+package certs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// KeyTypeRSA 2048 bit rsa keys
+	KeyTypeRSA = "rsa"
+	// KeyTypeECDSA p-256 ecdsa keys
+	KeyTypeECDSA = "ecdsa"
+
+	// CACertFile file name of the ca certificate
+	CACertFile = "ca.crt"
+	// CAKeyFile file name of the ca key
+	CAKeyFile = "ca.key"
+	// CertFile file name of the serving certificate
+	CertFile = "tls.crt"
+	// KeyFile file name of the serving key
+	KeyFile = "tls.key"
+
+	rsaKeyBits = 2048
+)
+
+// SelfSignedOptions self signed ca and serving certificate options
+type SelfSignedOptions struct {
+	// Hosts dns names and ip addresses of the serving certificate
+	Hosts    []string
+	Validity time.Duration
+	KeyType  string
+}
+
+// Bundle pem encoded ca and serving key pair
+type Bundle struct {
+	CACert []byte
+	CAKey  []byte
+	Cert   []byte
+	Key    []byte
+}
+
+// ServiceHosts dns names of a kubernetes service
+func ServiceHosts(name, namespace string) []string {
+	return []string{
+		name,
+		fmt.Sprintf("%s.%s", name, namespace),
+		fmt.Sprintf("%s.%s.svc", name, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace),
+	}
+}
+
+// GenerateSelfSigned generate a ca and a serving certificate signed by it for the hosts
+func GenerateSelfSigned(opts SelfSignedOptions) (*Bundle, error) {
+	if len(opts.Hosts) == 0 {
+		return nil, fmt.Errorf("at least one host is required")
+	}
+
+	if opts.Validity <= 0 {
+		return nil, fmt.Errorf("invalid validity %s", opts.Validity)
+	}
+
+	caKey, err := generateKey(opts.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := generateKey(opts.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	// back dated for clock skew between the webhook and the api server
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(opts.Validity)
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s-ca", opts.Hosts[0])},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDer, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create ca certificate: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: opts.Hosts[0]},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, h := range opts.Hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caTmpl, key.Public(), caKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create serving certificate: %v", err)
+	}
+
+	caKeyPem, err := encodeKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPem, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bundle{
+		CACert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDer}),
+		CAKey:  caKeyPem,
+		Cert:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Key:    keyPem,
+	}, nil
+}
+
+// CABundle base64 encoded ca certificate for the webhook configurations
+func (b *Bundle) CABundle() string {
+	return base64.StdEncoding.EncodeToString(b.CACert)
+}
+
+// WriteFiles write the serving key pair to the files and the ca next to the certificate
+func (b *Bundle) WriteFiles(certFile, keyFile string) error {
+	caDir := filepath.Dir(certFile)
+
+	files := []struct {
+		name string
+		data []byte
+		mode os.FileMode
+	}{
+		{name: filepath.Join(caDir, CACertFile), data: b.CACert, mode: 0644},
+		{name: filepath.Join(caDir, CAKeyFile), data: b.CAKey, mode: 0600},
+		{name: keyFile, data: b.Key, mode: 0600},
+		// the certificate is written last so that a watcher reloads a matching key pair
+		{name: certFile, data: b.Cert, mode: 0644},
+	}
+
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Dir(f.name), 0755); err != nil {
+			return fmt.Errorf("unable to create directory for %s: %v", f.name, err)
+		}
+
+		if err := ioutil.WriteFile(f.name, f.data, f.mode); err != nil {
+			return fmt.Errorf("unable to write %s: %v", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func generateKey(keyType string) (crypto.Signer, error) {
+	switch strings.ToLower(keyType) {
+	case KeyTypeRSA, "":
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case KeyTypeECDSA:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	default:
+		return nil, fmt.Errorf("invalid key type %s, expected %s or %s", keyType, KeyTypeRSA, KeyTypeECDSA)
+	}
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode ecdsa key: %v", err)
+		}
+
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("unsupported key %T", key)
+	}
+}
+
+func serialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+
+	return serial
+}
@@ -0,0 +1,92 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSelfSigned(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    SelfSignedOptions
+		wantErr bool
+	}{
+		{name: "success rsa", opts: SelfSignedOptions{Hosts: ServiceHosts("webhook", "estore"), Validity: time.Hour, KeyType: KeyTypeRSA}},
+		{name: "success ecdsa with ip", opts: SelfSignedOptions{Hosts: []string{"localhost", "127.0.0.1"}, Validity: time.Hour, KeyType: KeyTypeECDSA}},
+		{name: "failure no hosts", opts: SelfSignedOptions{Validity: time.Hour}, wantErr: true},
+		{name: "failure invalid validity", opts: SelfSignedOptions{Hosts: []string{"localhost"}}, wantErr: true},
+		{name: "failure invalid key type", opts: SelfSignedOptions{Hosts: []string{"localhost"}, Validity: time.Hour, KeyType: "dsa"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateSelfSigned(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateSelfSigned() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			keyPair, err := tls.X509KeyPair(got.Cert, got.Key)
+			if err != nil {
+				t.Fatalf("invalid key pair: %v", err)
+			}
+
+			leaf, err := x509.ParseCertificate(keyPair.Certificate[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			roots := x509.NewCertPool()
+			assert.True(t, roots.AppendCertsFromPEM(got.CACert))
+
+			for _, h := range tt.opts.Hosts {
+				_, err := leaf.Verify(x509.VerifyOptions{DNSName: h, Roots: roots})
+				assert.NoError(t, err, h)
+			}
+
+			assert.WithinDuration(t, time.Now().Add(tt.opts.Validity), leaf.NotAfter, time.Minute)
+
+			caBundle, err := base64.StdEncoding.DecodeString(got.CABundle())
+			assert.NoError(t, err)
+			assert.Equal(t, got.CACert, caBundle)
+		})
+	}
+}
+
+func TestBundle_WriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := GenerateSelfSigned(SelfSignedOptions{Hosts: []string{"localhost"}, Validity: time.Hour, KeyType: KeyTypeECDSA})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "out", CertFile), filepath.Join(dir, "out", KeyFile)
+	if err := b.WriteFiles(certFile, keyFile); err != nil {
+		t.Fatalf("WriteFiles() error = %v", err)
+	}
+
+	for _, f := range []string{CACertFile, CAKeyFile, CertFile, KeyFile} {
+		_, err := os.Stat(filepath.Join(dir, "out", f))
+		assert.NoError(t, err, f)
+	}
+
+	// the written key pair is loadable by the watcher
+	_, err = NewWatcher(certFile, keyFile)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,128 @@
+// Package certs provides the webhook serving certificates
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	lc "github.com/arutselvan15/go-utils/logconstants"
+
+	cLog "github.com/arutselvan15/estore-product-kube-webhook/log"
+	"github.com/arutselvan15/estore-product-kube-webhook/metrics"
+)
+
+var log = cLog.GetLogger()
+
+// Watcher serves the key pair of the certificate files and reloads it when the files change
+type Watcher struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	onReload []func()
+}
+
+// NewWatcher watcher with the key pair loaded
+func NewWatcher(certFile, keyFile string) (*Watcher, error) {
+	w := &Watcher{certFile: certFile, keyFile: keyFile}
+
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// GetCertificate tls.Config GetCertificate callback returning the current key pair
+func (w *Watcher) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.cert, nil
+}
+
+// Reload load the key pair and swap it in, the current key pair is kept on error
+func (w *Watcher) Reload() error {
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load tls certificate %s and key %s: %v", w.certFile, w.keyFile, err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse tls certificate %s: %v", w.certFile, err)
+	}
+
+	cert.Leaf = leaf
+
+	w.mu.Lock()
+	w.cert = &cert
+	callbacks := w.onReload
+	w.mu.Unlock()
+
+	metrics.SetCertificateExpiry(leaf.NotAfter)
+	log.Infof("tls certificate %s loaded, expires at %s", w.certFile, leaf.NotAfter.Format(time.RFC3339))
+
+	for _, fn := range callbacks {
+		fn()
+	}
+
+	return nil
+}
+
+// OnReload register a callback run after every successful reload
+func (w *Watcher) OnReload(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.onReload = append(w.onReload, fn)
+}
+
+// Start watch the certificate directories until the stop channel is closed, kubernetes secret volumes
+// are updated by swapping a symlink so the directories are watched instead of the files
+func (w *Watcher) Start(stopCh <-chan struct{}) error {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to create certificate watcher: %v", err)
+	}
+
+	dirs := map[string]bool{filepath.Dir(w.certFile): true, filepath.Dir(w.keyFile): true}
+	for dir := range dirs {
+		if err := fsWatcher.Add(dir); err != nil {
+			_ = fsWatcher.Close()
+			return fmt.Errorf("unable to watch certificate directory %s: %v", dir, err)
+		}
+	}
+
+	go func() {
+		defer fsWatcher.Close()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case event := <-fsWatcher.Events:
+				// chmod only events do not change the content
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+
+				if err := w.Reload(); err != nil {
+					// files may be half written, the next event reloads them
+					log.SetStepState(lc.Error).Error(err.Error())
+				}
+			case err := <-fsWatcher.Errors:
+				log.SetStepState(lc.Error).Errorf("certificate watcher error: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKeyPair write a self signed key pair valid until notAfter
+func writeKeyPair(t *testing.T, certFile, keyFile string, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "webhook.estore.svc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     []string{"webhook.estore.svc"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	firstExpiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	writeKeyPair(t, certFile, keyFile, firstExpiry)
+
+	w, err := NewWatcher(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	cert, _ := w.GetCertificate(nil)
+	assert.True(t, cert.Leaf.NotAfter.Equal(firstExpiry))
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	if err := w.Start(stopCh); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	rotatedExpiry := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	writeKeyPair(t, certFile, keyFile, rotatedExpiry)
+
+	assert.Eventually(t, func() bool {
+		cert, _ := w.GetCertificate(nil)
+		return cert.Leaf.NotAfter.Equal(rotatedExpiry)
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestWatcher_ReloadKeepsCertificateOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeKeyPair(t, certFile, keyFile, time.Now().Add(time.Hour))
+
+	w, err := NewWatcher(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	reloads := 0
+	w.OnReload(func() { reloads++ })
+
+	assert.NoError(t, w.Reload())
+	assert.Equal(t, 1, reloads)
+
+	_ = ioutil.WriteFile(keyFile, []byte("partial"), 0600)
+
+	assert.Error(t, w.Reload())
+	assert.Equal(t, 1, reloads, "callbacks must not run for failed reloads")
+
+	cert, _ := w.GetCertificate(nil)
+	assert.NotNil(t, cert)
+}
+
+func TestNewWatcher_missingFiles(t *testing.T) {
+	_, err := NewWatcher("/does/not/exist/tls.crt", "/does/not/exist/tls.key")
+	assert.Error(t, err)
+}
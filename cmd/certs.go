@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arutselvan15/estore-product-kube-webhook/certs"
+)
+
+// certsCmd generate a self signed ca and serving certificate for the webhook service and print the base64
+// ca bundle, returns the exit code
+func certsCmd(args []string) int {
+	var (
+		name, namespace, hosts string
+		outDir, keyType        string
+		validity               time.Duration
+	)
+
+	fs := flag.NewFlagSet("certs", flag.ExitOnError)
+	fs.StringVar(&name, "name", "estore-product-kube-webhook", "Name of the webhook service.")
+	fs.StringVar(&namespace, "namespace", "estore-system", "Namespace of the webhook service.")
+	fs.StringVar(&hosts, "hosts", "", "Comma separated additional dns names and ip addresses of the serving certificate.")
+	fs.StringVar(&outDir, "outDir", "certs", "Directory to write ca.crt, ca.key, tls.crt and tls.key to.")
+	fs.StringVar(&keyType, "keyType", certs.KeyTypeRSA, "Key type, rsa or ecdsa.")
+	fs.DurationVar(&validity, "validity", 365*24*time.Hour, "Validity of the ca and serving certificate.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s certs [flags]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Generates a self signed ca and a serving certificate and prints the base64 ca bundle.\n\n")
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+
+	bundle, err := certs.GenerateSelfSigned(certs.SelfSignedOptions{
+		Hosts:    append(certs.ServiceHosts(name, namespace), splitArg(hosts)...),
+		Validity: validity,
+		KeyType:  keyType,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error generating certificates: %v\n", err)
+		return 2
+	}
+
+	if err := bundle.WriteFiles(filepath.Join(outDir, certs.CertFile), filepath.Join(outDir, certs.KeyFile)); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing certificates: %v\n", err)
+		return 1
+	}
+
+	fmt.Println(bundle.CABundle())
+
+	return 0
+}
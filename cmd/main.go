@@ -1,32 +1,123 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/spf13/viper"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
 	cc "github.com/arutselvan15/estore-common/clients"
 	gc "github.com/arutselvan15/estore-common/config"
+	"github.com/arutselvan15/estore-common/signals"
+	lc "github.com/arutselvan15/go-utils/logconstants"
 
+	"github.com/arutselvan15/estore-product-kube-webhook/catalog"
+	"github.com/arutselvan15/estore-product-kube-webhook/certs"
 	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+	"github.com/arutselvan15/estore-product-kube-webhook/health"
+	cLog "github.com/arutselvan15/estore-product-kube-webhook/log"
+	"github.com/arutselvan15/estore-product-kube-webhook/manifests"
+	"github.com/arutselvan15/estore-product-kube-webhook/metrics"
 	"github.com/arutselvan15/estore-product-kube-webhook/webhook"
 )
 
+var log = cLog.GetLogger()
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "review":
+			os.Exit(reviewCmd(os.Args[2:]))
+		case "manifests":
+			os.Exit(manifestsCmd(os.Args[2:]))
+		case "certs":
+			os.Exit(certsCmd(os.Args[2:]))
+		}
+	}
+
 	var (
-		port, certFile, keyFile string
-		config                  *rest.Config
-		err                     error
+		port, metricsPort  string
+		certFile, keyFile  string
+		versionFile        string
+		selfSigned         bool
+		selfSignedHosts    string
+		selfSignedKeyType  string
+		selfSignedValidity time.Duration
+		register           bool
+		excludeNamespaces  string
+		caFile             string
+		registerOpts       manifests.Options
+		timeoutSeconds     int
+		cacheResync        time.Duration
+		drainPeriod        time.Duration
+		shutdownTimeout    time.Duration
+		config             *rest.Config
+		err                error
 	)
 
 	flag.StringVar(&port, "port", "8000", "Webhook server port.")
+	flag.StringVar(&metricsPort, "metricsPort", "8080", "Metrics and health server plain http port.")
 	flag.StringVar(&certFile, "tlsCertFile", "/etc/webhook/certs/tls.crt", "File containing the x509 Certificate for HTTPS.")
 	flag.StringVar(&keyFile, "tlsKeyFile", "/etc/webhook/certs/tls.key", "File containing the x509 private key to --tlsCertFile.")
+	flag.BoolVar(&selfSigned, "selfSigned", false, "Generate a self signed ca and key pair into --tlsCertFile and --tlsKeyFile on startup, "+
+		"every replica gets its own ca so it can not be combined with --register.")
+	flag.StringVar(&selfSignedHosts, "selfSignedHosts", "", "Comma separated additional dns names and ip addresses of the --selfSigned certificate, "+
+		"the service dns names of --serviceName and --serviceNamespace are always included.")
+	flag.StringVar(&selfSignedKeyType, "selfSignedKeyType", certs.KeyTypeRSA, "Key type of the --selfSigned certificate, rsa or ecdsa.")
+	flag.DurationVar(&selfSignedValidity, "selfSignedValidity", 365*24*time.Hour, "Validity of the --selfSigned ca and certificate.")
+	flag.BoolVar(&register, "register", false, "Create or update the webhook configurations on startup and on certificate reload.")
+	flag.StringVar(&caFile, "tlsCAFile", "", "File containing the ca of --tlsCertFile for --register, defaults to ca.crt next to it.")
+	flag.StringVar(&registerOpts.Name, "serviceName", "estore-product-kube-webhook", "Webhook service and configurations name for --register and --selfSigned.")
+	flag.StringVar(&registerOpts.Namespace, "serviceNamespace", "estore-system", "Webhook service namespace for --register and --selfSigned.")
+	flag.StringVar(&registerOpts.FailurePolicy, "failurePolicy", "Fail", "Webhook failure policy for --register, Fail or Ignore.")
+	flag.StringVar(&registerOpts.SideEffects, "sideEffects", "None", "Webhook side effects for --register, None or NoneOnDryRun.")
+	flag.IntVar(&timeoutSeconds, "timeoutSeconds", 10, "Webhook timeout in seconds for --register, 1 to 30.")
+	flag.StringVar(&excludeNamespaces, "excludeNamespaces", "", "Comma separated namespace names, not prefixes, not sent to the webhook "+
+		"for --register, in addition to the webhook and system namespaces.")
+	flag.StringVar(&versionFile, "versionFile", "/etc/version.txt", "File generated by make gen-version.")
+	flag.DurationVar(&cacheResync, "cacheResync", 10*time.Minute, "Resync period of the product cache, 0 disables it.")
+	flag.DurationVar(&drainPeriod, "drainPeriod", 5*time.Second, "Time to keep serving after readiness is flipped on shutdown.")
+	flag.DurationVar(&shutdownTimeout, "shutdownTimeout", 30*time.Second, "Time to wait for in-flight requests on shutdown.")
 	flag.Parse()
 
+	// the replicas would overwrite the ca bundle registered by each other
+	if selfSigned && register {
+		fmt.Fprintln(os.Stderr, "--selfSigned can not be combined with --register, generate a shared ca with the certs command")
+		os.Exit(2)
+	}
+
+	// closed on SIGTERM or SIGINT
+	stopCh := signals.SetupSignalHandler()
+
+	probes := health.New(versionFile, health.TLS, health.Clients, health.Config, health.Cache, health.Serving)
+	probes.SetReady(health.Config, configLoaded())
+
+	// metrics and probes are served on plain http so that they work without the webhook certificates
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", metrics.Handler())
+	probes.Register(metricsMux)
+
+	metricsServer := &http.Server{
+		Addr:    fmt.Sprintf(":%v", metricsPort),
+		Handler: metricsMux,
+	}
+
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.SetStepState(lc.Error).Errorf("error unable to start the metrics server: %v", err)
+			os.Exit(1)
+		}
+	}()
+
 	// kube config defined in env
 	if gc.GetKubeConfigPath() != "" {
 		config, err = clientcmd.BuildConfigFromFlags("", gc.GetKubeConfigPath())
@@ -47,9 +138,24 @@ func main() {
 		panic(fmt.Sprintf("error creating clients: %v", err))
 	}
 
+	probes.SetReady(health.Clients, true)
+
+	// validations across products read the cache, the pod is not ready until the initial list is cached
+	productCache, err := catalog.New(estoreClients.GetProductClient(), cacheResync)
+	if err != nil {
+		panic(fmt.Sprintf("error creating product cache: %v", err))
+	}
+
+	productCache.Start(stopCh)
+
+	go func() {
+		probes.SetReady(health.Cache, productCache.WaitForSync(stopCh))
+	}()
+
 	// web hook server
 	whsvr := webhook.Server{
-		Clients: estoreClients,
+		Clients:  estoreClients,
+		Products: productCache,
 	}
 
 	// define http server and server handler
@@ -57,15 +163,122 @@ func main() {
 	mux.HandleFunc(cfg.MutateURL, whsvr.Serve)
 	mux.HandleFunc(cfg.ValidateURL, whsvr.Serve)
 
+	if selfSigned {
+		// the api server calls the webhook by its service dns name
+		bundle, err := certs.GenerateSelfSigned(certs.SelfSignedOptions{
+			Hosts:    append(certs.ServiceHosts(registerOpts.Name, registerOpts.Namespace), splitArg(selfSignedHosts)...),
+			Validity: selfSignedValidity,
+			KeyType:  selfSignedKeyType,
+		})
+		if err == nil {
+			err = bundle.WriteFiles(certFile, keyFile)
+		}
+
+		if err != nil {
+			panic(fmt.Sprintf("error generating self signed certificate: %v", err))
+		}
+
+		log.Infof("self signed certificate written to %s, ca bundle %s", certFile, bundle.CABundle())
+	}
+
+	// tls material must be loadable before the server reports ready, rotated files are picked up by the watcher
+	certWatcher, err := certs.NewWatcher(certFile, keyFile)
+	if err != nil {
+		panic(fmt.Sprintf("error loading tls certificate and key: %v", err))
+	}
+
+	if err = certWatcher.Start(stopCh); err != nil {
+		panic(fmt.Sprintf("error watching tls certificate and key: %v", err))
+	}
+
+	if register {
+		if caFile == "" {
+			caFile = filepath.Join(filepath.Dir(certFile), certs.CACertFile)
+		}
+
+		registerOpts.TimeoutSeconds = int32(timeoutSeconds)
+		setNamespaceOptions(&registerOpts, excludeNamespaces)
+
+		// a failed registration e.g. before the rbac is in place is retried without restarting the pod
+		registrar := &webhookRegistrar{client: estoreClients.GetKubeClient(), opts: registerOpts, caFile: caFile}
+		go registrar.registerUntilDone(registerRetryPeriod, stopCh)
+
+		// rotated certificates may come with a new ca
+		certWatcher.OnReload(func() {
+			if err := registrar.register(); err != nil {
+				log.SetStepState(lc.Error).Error(err.Error())
+			}
+		})
+	}
+
+	probes.SetReady(health.TLS, true)
+
 	server := &http.Server{
 		// We listen on port 8443 such that we do not need root privileges or extra capabilities for this server.
 		// The Service object will take care of mapping this port to the HTTPS port 443.
-		Addr:    fmt.Sprintf(":%v", port),
-		Handler: mux,
+		Addr:      fmt.Sprintf(":%v", port),
+		Handler:   mux,
+		TLSConfig: &tls.Config{GetCertificate: certWatcher.GetCertificate},
 	}
-	err = server.ListenAndServeTLS(certFile, keyFile)
 
+	// the port is bound before the server reports ready so that a bind failure is never reported as serving
+	listener, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		panic(fmt.Sprintf("error unable to seart the server: %v", err))
+		log.SetStepState(lc.Error).Errorf("error unable to start the server: %v", err)
+		os.Exit(1)
 	}
+
+	serverErrCh := make(chan error, 1)
+
+	go func() {
+		serverErrCh <- server.ServeTLS(listener, "", "")
+	}()
+
+	probes.SetReady(health.Serving, true)
+
+	select {
+	case err = <-serverErrCh:
+		log.SetStepState(lc.Error).Errorf("error unable to start the server: %v", err)
+		os.Exit(1)
+	case <-stopCh:
+	}
+
+	os.Exit(shutdown(probes, drainPeriod, shutdownTimeout, server, metricsServer))
+}
+
+// configLoaded read the config file from the config paths, the app name does not tell a config was read as the
+// APP_NAME env var also sets it
+func configLoaded() bool {
+	if err := viper.ReadInConfig(); err != nil {
+		log.SetStepState(lc.Error).Errorf("error reading config: %v", err)
+		return false
+	}
+
+	log.Infof("config %s loaded", viper.ConfigFileUsed())
+
+	return true
+}
+
+// shutdown stop reporting ready so that endpoints drop the pod, keep serving for the drain period and then
+// wait for in-flight admission requests, returns the process exit code
+func shutdown(probes *health.Health, drainPeriod, timeout time.Duration, server, metricsServer *http.Server) int {
+	log.Infof("shutdown requested, draining for %s", drainPeriod)
+	probes.SetReady(health.Serving, false)
+
+	time.Sleep(drainPeriod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.SetStepState(lc.Error).Errorf("error shutting down the server: %v", err)
+		return 1
+	}
+
+	// metrics and probes are kept until the webhook server is done
+	_ = metricsServer.Shutdown(ctx)
+
+	log.SetStepState(lc.Complete).Info("server shutdown completed")
+
+	return 0
 }
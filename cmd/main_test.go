@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arutselvan15/estore-product-kube-webhook/health"
+)
+
+// startServer serve the handler on a free local port, returns the server and its url
+func startServer(t *testing.T, handler http.Handler) (*http.Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{Handler: handler}
+
+	go func() { _ = server.Serve(listener) }()
+
+	return server, "http://" + listener.Addr().String()
+}
+
+func Test_shutdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		inFlight time.Duration
+		timeout  time.Duration
+		want     int
+	}{
+		{name: "success in-flight request drained", inFlight: 100 * time.Millisecond, timeout: 5 * time.Second, want: 0},
+		{name: "failure in-flight request exceeds timeout", inFlight: 2 * time.Second, timeout: 50 * time.Millisecond, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+
+			server, url := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				close(started)
+				time.Sleep(tt.inFlight)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			metricsServer, _ := startServer(t, http.NotFoundHandler())
+			defer metricsServer.Close()
+
+			probes := health.New("", health.Serving)
+			probes.SetReady(health.Serving, true)
+
+			respCh := make(chan error, 1)
+
+			go func() {
+				resp, err := http.Get(url)
+				if err == nil {
+					_ = resp.Body.Close()
+				}
+				respCh <- err
+			}()
+
+			<-started
+
+			got := shutdown(probes, 10*time.Millisecond, tt.timeout, server, metricsServer)
+			assert.Equal(t, tt.want, got)
+
+			// readiness is flipped so that the endpoints drop the pod
+			ready, _ := probes.Ready()
+			assert.False(t, ready)
+
+			if tt.want == 0 {
+				assert.NoError(t, <-respCh, "in-flight request must complete")
+			}
+		})
+	}
+}
+
+func Test_configLoaded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer viper.Reset()
+
+	// the app name of the env var is not a config
+	viper.Reset()
+	viper.SetConfigName("config")
+	viper.AddConfigPath(dir)
+	_ = viper.BindEnv("app.name", "APP_NAME")
+	_ = os.Setenv("APP_NAME", "estore-product-kube-webhook")
+	defer os.Unsetenv("APP_NAME")
+
+	assert.False(t, configLoaded())
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte("app:\n  freeze:\n    message: frozen\n"),
+		0600); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, configLoaded())
+	assert.Equal(t, "frozen", viper.GetString("app.freeze.message"))
+}
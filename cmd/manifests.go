@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	gc "github.com/arutselvan15/estore-common/config"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+	"github.com/arutselvan15/estore-product-kube-webhook/manifests"
+)
+
+// manifestsCmd print the webhook service account and rbac, config map, service, deployment and webhook
+// configurations, returns the exit code
+func manifestsCmd(args []string) int {
+	var (
+		opts                 manifests.Options
+		port, metricsPort    int
+		replicas, timeout    int
+		caFile, caBundle     string
+		configFile           string
+		excludeNamespacesArg string
+	)
+
+	fs := flag.NewFlagSet("manifests", flag.ExitOnError)
+	fs.StringVar(&opts.Name, "name", "estore-product-kube-webhook", "Name of the service, deployment and webhook configurations.")
+	fs.StringVar(&opts.Namespace, "namespace", "estore-system", "Namespace of the webhook service and deployment.")
+	fs.StringVar(&opts.Image, "image", "estore-product-kube-webhook:latest", "Webhook container image.")
+	fs.StringVar(&opts.TLSSecret, "tlsSecret", "estore-product-kube-webhook-certs", "Secret with tls.crt and tls.key.")
+	fs.StringVar(&opts.FailurePolicy, "failurePolicy", "Fail", "Webhook failure policy, Fail or Ignore.")
+	fs.StringVar(&opts.SideEffects, "sideEffects", "None", "Webhook side effects, None or NoneOnDryRun.")
+	fs.IntVar(&timeout, "timeoutSeconds", 10, "Webhook timeout in seconds, 1 to 30.")
+	fs.IntVar(&replicas, "replicas", 2, "Webhook replicas.")
+	fs.IntVar(&port, "port", 8000, "Webhook server port.")
+	fs.IntVar(&metricsPort, "metricsPort", 8080, "Metrics and health server plain http port.")
+	fs.StringVar(&caFile, "caFile", "", "File containing the pem encoded ca of the serving certificate.")
+	fs.StringVar(&caBundle, "caBundle", "", "Base64 encoded pem ca of the serving certificate, instead of --caFile.")
+	fs.StringVar(&excludeNamespacesArg, "excludeNamespaces", "",
+		"Comma separated namespace names, not prefixes, not sent to the webhook, in addition to the webhook and system namespaces.")
+	fs.BoolVar(&opts.Register, "register", false,
+		"Run the webhook with --register and grant its service account the webhook configurations.")
+	fs.StringVar(&configFile, "config", "", "Config file, defaults to config.yaml in /etc/viper or the working directory, deployed as a ConfigMap mounted at /etc/viper.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s manifests [flags]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Prints the webhook ServiceAccount, ClusterRole, ClusterRoleBinding, ConfigMap, Service,\n")
+		fmt.Fprintf(fs.Output(), "Deployment and webhook configurations as yaml.\n")
+		fmt.Fprintf(fs.Output(), "Namespaces are excluded by the %s label which requires kubernetes 1.21 or later,\n",
+			manifests.NamespaceNameLabel)
+		fmt.Fprintf(fs.Output(), "older clusters send every namespace to the webhook.\n\n")
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+
+	if configFile != "" {
+		viper.SetConfigFile(configFile)
+
+		if err := viper.ReadInConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config %s: %v\n", configFile, err)
+			return 2
+		}
+	}
+
+	// the webhook is deployed with the config the namespace selector is generated from
+	if used := viper.ConfigFileUsed(); used != "" {
+		data, err := ioutil.ReadFile(used)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config %s: %v\n", used, err)
+			return 2
+		}
+
+		opts.Config = data
+	} else {
+		fmt.Fprintf(os.Stderr, "warning: no config file found, the webhook is deployed without config, pass --config\n")
+	}
+
+	opts.Port, opts.MetricsPort = int32(port), int32(metricsPort)
+	opts.Replicas, opts.TimeoutSeconds = int32(replicas), int32(timeout)
+
+	switch {
+	case caBundle != "":
+		data, err := base64.StdEncoding.DecodeString(caBundle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error decoding ca bundle: %v\n", err)
+			return 2
+		}
+
+		opts.CABundle = data
+	case caFile != "":
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading ca file %s: %v\n", caFile, err)
+			return 2
+		}
+
+		opts.CABundle = data
+	}
+
+	setNamespaceOptions(&opts, excludeNamespacesArg)
+
+	objects, err := manifests.Generate(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error generating manifests: %v\n", err)
+		return 2
+	}
+
+	if err := manifests.Write(os.Stdout, objects); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing manifests: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// setNamespaceOptions exclude the given namespace names and the system namespaces of the config by name, the
+// namespaces matched by system prefixes and patterns which a label selector can not express are sent to the webhook
+// and bypassed there, black listed namespaces of the config are kept
+func setNamespaceOptions(opts *manifests.Options, excludeNamespaces string) {
+	opts.ExcludeNamespaces = splitArg(excludeNamespaces)
+	opts.SystemNamespaces = cfg.GetSubjectList(cfg.SubjectListSystem).Namespaces
+	opts.BlacklistNamespaces = sortedKeys(gc.GetBlacklistNamespaces())
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func splitArg(arg string) []string {
+	var items []string
+
+	for _, i := range strings.Split(arg, ",") {
+		if i = strings.TrimSpace(i); i != "" {
+			items = append(items, i)
+		}
+	}
+
+	return items
+}
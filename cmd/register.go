@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	lc "github.com/arutselvan15/go-utils/logconstants"
+
+	"github.com/arutselvan15/estore-product-kube-webhook/manifests"
+)
+
+// registerRetryPeriod wait between the attempts of a failed startup registration
+const registerRetryPeriod = 10 * time.Second
+
+// webhookRegistrar create or update the webhook configurations with the ca read from the ca file, a secret
+// rotation fires several reloads so the configurations are only updated when the ca changes
+type webhookRegistrar struct {
+	client kubernetes.Interface
+	opts   manifests.Options
+	caFile string
+
+	mu         sync.Mutex
+	registered []byte
+}
+
+// register the webhook configurations unless they already carry the ca of the ca file
+func (r *webhookRegistrar) register() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	caBundle, err := ioutil.ReadFile(r.caFile)
+	if err != nil {
+		return fmt.Errorf("unable to read ca file %s: %v", r.caFile, err)
+	}
+
+	if r.registered != nil && bytes.Equal(caBundle, r.registered) {
+		return nil
+	}
+
+	opts := r.opts
+	opts.CABundle = caBundle
+
+	if err := manifests.Register(r.client, opts); err != nil {
+		return err
+	}
+
+	r.registered = caBundle
+
+	log.Infof("webhook configurations %s registered with ca %s", opts.Name, r.caFile)
+
+	return nil
+}
+
+// registerUntilDone register the webhook configurations, failures are logged and retried every period until the
+// registration succeeds or the stop channel is closed
+func (r *webhookRegistrar) registerUntilDone(period time.Duration, stopCh <-chan struct{}) {
+	_ = wait.PollImmediateUntil(period, func() (bool, error) {
+		if err := r.register(); err != nil {
+			log.SetStepState(lc.Error).Errorf("%v, retrying in %s", err, period)
+			return false, nil
+		}
+
+		return true, nil
+	}, stopCh)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/arutselvan15/estore-product-kube-webhook/manifests"
+)
+
+func TestWebhookRegistrar_register(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registrar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := ioutil.WriteFile(caFile, []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client := fake.NewSimpleClientset()
+	registrar := &webhookRegistrar{client: client, caFile: caFile, opts: manifests.Options{
+		Name:           "product-webhook",
+		Namespace:      "estore-system",
+		Port:           8000,
+		FailurePolicy:  "Fail",
+		SideEffects:    "None",
+		TimeoutSeconds: 10,
+	}}
+
+	// first registration and the reloads of the same ca
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, registrar.register())
+	}
+	registered := len(client.Actions())
+	assert.NotZero(t, registered)
+
+	// rotated ca
+	if err := ioutil.WriteFile(caFile, []byte("rotated-ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, registrar.register())
+	assert.Equal(t, 2*registered, len(client.Actions()))
+	assert.NoError(t, registrar.register())
+	assert.Equal(t, 2*registered, len(client.Actions()))
+
+	mwc, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get("product-webhook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("mutating webhook configuration not found: %v", err)
+	}
+	assert.Equal(t, []byte("rotated-ca"), mwc.Webhooks[0].ClientConfig.CABundle)
+
+	// missing ca file
+	registrar.caFile = filepath.Join(dir, "missing.crt")
+	assert.Error(t, registrar.register())
+}
+
+func TestWebhookRegistrar_registerUntilDone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registrar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	if err := ioutil.WriteFile(caFile, []byte("ca"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first attempts fail e.g. while the rbac is not in place
+	client := fake.NewSimpleClientset()
+	attempts := 0
+	client.PrependReactor("get", "mutatingwebhookconfigurations",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if attempts++; attempts < 3 {
+				return true, nil, fmt.Errorf("forbidden")
+			}
+
+			return false, nil, nil
+		})
+
+	registrar := &webhookRegistrar{client: client, caFile: caFile, opts: manifests.Options{
+		Name:           "product-webhook",
+		Namespace:      "estore-system",
+		Port:           8000,
+		FailurePolicy:  "Fail",
+		SideEffects:    "None",
+		TimeoutSeconds: 10,
+	}}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	done := make(chan struct{})
+
+	go func() {
+		registrar.registerUntilDone(10*time.Millisecond, stopCh)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("registerUntilDone() did not return after a successful registration")
+	}
+
+	assert.Equal(t, 3, attempts)
+
+	_, err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get("product-webhook", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/spf13/viper"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+	"github.com/arutselvan15/estore-product-kube-webhook/webhook"
+)
+
+const stdinFile = "-"
+
+// reviewCmd evaluate admission review files offline and print the responses, returns 1 when a review is denied
+// and 2 on usage or read errors
+func reviewCmd(args []string) int {
+	var reqPath, configFile string
+
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	fs.StringVar(&reqPath, "path", cfg.ValidateURL, "Webhook path to review with, "+cfg.MutateURL+" or "+cfg.ValidateURL+".")
+	fs.StringVar(&configFile, "config", "", "Config file, defaults to config.yaml in /etc/viper or the working directory.")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s review [flags] [file ...]\n\n", os.Args[0])
+		fmt.Fprintf(fs.Output(), "Reviews AdmissionReview json or yaml files, stdin when no file or - is given.\n\n")
+		fs.PrintDefaults()
+	}
+
+	_ = fs.Parse(args)
+
+	if reqPath != cfg.MutateURL && reqPath != cfg.ValidateURL {
+		fmt.Fprintf(os.Stderr, "invalid path %s, expected %s or %s\n", reqPath, cfg.MutateURL, cfg.ValidateURL)
+		return 2
+	}
+
+	if configFile != "" {
+		viper.SetConfigFile(configFile)
+
+		if err := viper.ReadInConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "error reading config %s: %v\n", configFile, err)
+			return 2
+		}
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{stdinFile}
+	}
+
+	// offline reviews do not reach a cluster
+	s := webhook.Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	code := 0
+
+	for _, f := range files {
+		data, err := readReviewFile(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading %s: %v\n", f, err)
+			return 2
+		}
+
+		result, err := s.Review(reqPath, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reviewing %s: %v\n", f, err)
+			return 2
+		}
+
+		printReview(os.Stdout, f, result)
+
+		if !result.Allowed {
+			code = 1
+		}
+	}
+
+	return code
+}
+
+func readReviewFile(file string) ([]byte, error) {
+	if file == stdinFile {
+		return ioutil.ReadAll(os.Stdin)
+	}
+
+	return ioutil.ReadFile(file)
+}
+
+func printReview(w io.Writer, file string, result *webhook.ReviewResult) {
+	decision := "allowed"
+	if !result.Allowed {
+		decision = "denied"
+	}
+
+	fmt.Fprintf(w, "==> %s: %s\n", file, decision)
+
+	if result.Message != "" {
+		fmt.Fprintf(w, "message: %s\n", result.Message)
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+
+	fmt.Fprintf(w, "response:\n%s\n", indentJSON(result.Response))
+
+	if result.Patch != nil {
+		fmt.Fprintf(w, "patch:\n%s\n", indentJSON(result.Patch))
+	}
+
+	if result.Patched != nil {
+		fmt.Fprintf(w, "patched object:\n%s\n", indentJSON(result.Patched))
+	}
+}
+
+// indentJSON indented json, the data unchanged when it is not json
+func indentJSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return data
+	}
+
+	return out
+}
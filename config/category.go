@@ -0,0 +1,32 @@
+package config
+
+import (
+	"github.com/spf13/viper"
+)
+
+// CategoryPolicy category catalog policy
+type CategoryPolicy struct {
+	// Taxonomy allowed categories, hierarchical categories are separated by /
+	Taxonomy []string
+	// Min minimum number of categories, 0 disables the check
+	Min int
+	// Max maximum number of categories, 0 disables the check
+	Max int
+	// Deprecated categories still accepted with a warning, includes their children
+	Deprecated []string
+}
+
+// GetCategoryPolicy category policy from app.categories
+func GetCategoryPolicy() CategoryPolicy {
+	return CategoryPolicy{
+		Taxonomy:   splitList(viper.GetString("app.categories.taxonomy")),
+		Min:        viper.GetInt("app.categories.min"),
+		Max:        viper.GetInt("app.categories.max"),
+		Deprecated: splitList(viper.GetString("app.categories.deprecated")),
+	}
+}
+
+// GetCategoryNormalize normalize categories on mutation, enabled unless set to false
+func GetCategoryNormalize() bool {
+	return !viper.IsSet("app.categories.normalize") || viper.GetBool("app.categories.normalize")
+}
@@ -1,6 +1,12 @@
 // Package config provides configurations
 package config
 
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
 const (
 	// ValidateURL ValidateURL
 	ValidateURL = "/validate"
@@ -14,4 +20,48 @@ const (
 	Update = "UPDATE"
 	// Delete action
 	Delete = "DELETE"
+
+	// ImmutableOverrideAnnotation default annotation to allow changing immutable fields
+	ImmutableOverrideAnnotation = "product.estore.com/allow-immutable-change"
+
+	// CreatedByAnnotation user who created the product, set by the mutating webhook
+	CreatedByAnnotation = "product.estore.com/created-by"
+	// CreatedAtAnnotation creation time of the product, set by the mutating webhook
+	CreatedAtAnnotation = "product.estore.com/created-at"
+	// LastUpdatedByAnnotation user of the last update, set by the mutating webhook
+	LastUpdatedByAnnotation = "product.estore.com/last-updated-by"
+	// LastUpdatedAtAnnotation time of the last update, set by the mutating webhook
+	LastUpdatedAtAnnotation = "product.estore.com/last-updated-at"
 )
+
+// GetImmutableFields product field paths which can not be changed on update
+func GetImmutableFields() []string {
+	return splitList(viper.GetString("app.immutable.fields"))
+}
+
+// GetImmutableOverrideAnnotation annotation to allow changing immutable fields
+func GetImmutableOverrideAnnotation() string {
+	if annotation := viper.GetString("app.immutable.overrideAnnotation"); annotation != "" {
+		return annotation
+	}
+
+	return ImmutableOverrideAnnotation
+}
+
+// GetImmutableOverrideGroups groups allowed to use the override annotation, entries match like the privileged list
+func GetImmutableOverrideGroups() []string {
+	return splitList(viper.GetString("app.immutable.overrideGroups"))
+}
+
+// splitList comma separated list
+func splitList(str string) []string {
+	var list []string
+
+	for _, i := range strings.Split(str, ",") {
+		if i = strings.TrimSpace(i); i != "" {
+			list = append(list, i)
+		}
+	}
+
+	return list
+}
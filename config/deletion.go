@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// DeleteProtectAnnotation default annotation protecting a product from deletion
+const DeleteProtectAnnotation = "estore.com/protect-delete"
+
+// DeletionSelector label selector protecting the matching products from deletion
+type DeletionSelector struct {
+	// Name rule name used in the denial
+	Name string `mapstructure:"name"`
+	// Selector label selector e.g. tier=flagship
+	Selector string `mapstructure:"selector"`
+}
+
+// DeletionPolicy product deletion protection policy
+type DeletionPolicy struct {
+	// ProtectAnnotation annotation set to "true" to protect the product
+	ProtectAnnotation string `mapstructure:"protectAnnotation"`
+	// Selectors protect the products matching any of the selectors
+	Selectors []DeletionSelector `mapstructure:"selectors"`
+	// AllowedGroups groups allowed to delete protected products, from the comma separated allowedGroups, entries
+	// match like the privileged list
+	AllowedGroups []string `mapstructure:"-"`
+}
+
+// GetDeletionPolicy deletion protection policy from app.deletion
+func GetDeletionPolicy() (DeletionPolicy, error) {
+	policy := DeletionPolicy{}
+
+	if err := viper.UnmarshalKey("app.deletion", &policy); err != nil {
+		return policy, fmt.Errorf("unable to load deletion policy. %s", err.Error())
+	}
+
+	if policy.ProtectAnnotation == "" {
+		policy.ProtectAnnotation = DeleteProtectAnnotation
+	}
+
+	policy.AllowedGroups = splitList(viper.GetString("app.deletion.allowedGroups"))
+
+	return policy, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// DuplicateScopeNamespace products are compared with the products of the same namespace
+	DuplicateScopeNamespace = "namespace"
+	// DuplicateScopeCluster products are compared with the products of all namespaces
+	DuplicateScopeCluster = "cluster"
+)
+
+// DuplicatePolicy duplicate product detection policy
+type DuplicatePolicy struct {
+	// Keys field paths of the identity key e.g. spec.brand, spec.displayName or metadata.labels[sku],
+	// products with equal values for all the fields are duplicates, empty disables the check
+	Keys []string
+	// Scope namespace or cluster, defaults to namespace
+	Scope string
+	// Enforcement enforce, warn or audit, defaults to enforce whatever the namespace enforcement mode
+	Enforcement string
+}
+
+// GetDuplicatePolicy duplicate policy from app.duplicates
+func GetDuplicatePolicy() DuplicatePolicy {
+	scope := strings.ToLower(strings.TrimSpace(viper.GetString("app.duplicates.scope")))
+	if scope != DuplicateScopeCluster {
+		scope = DuplicateScopeNamespace
+	}
+
+	return DuplicatePolicy{
+		Keys:        splitList(viper.GetString("app.duplicates.keys")),
+		Scope:       scope,
+		Enforcement: guardEnforcement(viper.GetString("app.duplicates.enforcement")),
+	}
+}
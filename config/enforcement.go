@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// EnforcementEnforce violations deny the request
+	EnforcementEnforce = "enforce"
+	// EnforcementWarn violations are returned as warnings and the request is allowed
+	EnforcementWarn = "warn"
+	// EnforcementAudit violations are only logged and counted
+	EnforcementAudit = "audit"
+)
+
+// GetEnforcementMode enforcement mode of the namespace from app.enforcement.namespaces, defaults to
+// app.enforcement.default
+func GetEnforcementMode(namespace string) string {
+	if mode, ok := viper.GetStringMapString("app.enforcement.namespaces")[strings.ToLower(namespace)]; ok {
+		return mode
+	}
+
+	return viper.GetString("app.enforcement.default")
+}
+
+// guardEnforcement enforcement mode of a guard check, unset enforces so that the namespace mode does not downgrade
+// the guard
+func guardEnforcement(mode string) string {
+	if strings.TrimSpace(mode) == "" {
+		return EnforcementEnforce
+	}
+
+	return mode
+}
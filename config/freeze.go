@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// FreezeComponentAll freeze all components
+	FreezeComponentAll = "all"
+	// FreezeComponentWebhook freeze all operations of the webhook
+	FreezeComponentWebhook = "webhook"
+)
+
+// FreezeWindow change freeze window, either absolute with startTime/endTime or recurring with days/start/end
+type FreezeWindow struct {
+	// Name window name used in logs
+	Name string `mapstructure:"name"`
+	// StartTime absolute start, RFC3339 or 2006-01-02T15:04:05 in the window timezone
+	StartTime string `mapstructure:"startTime"`
+	// EndTime absolute end, empty means no end
+	EndTime string `mapstructure:"endTime"`
+	// Days recurring week days e.g. friday, empty means every day
+	Days []string `mapstructure:"days"`
+	// Start recurring start clock time 15:04, empty starts at midnight
+	Start string `mapstructure:"start"`
+	// End recurring end clock time 15:04, an end before start ends on the next day, empty ends at midnight
+	End string `mapstructure:"end"`
+	// Timezone IANA timezone of the window, defaults to UTC
+	Timezone string `mapstructure:"timezone"`
+	// Message denial message, defaults to app.freeze.message
+	Message string `mapstructure:"message"`
+	// Components comma separated all, webhook or operations create, update, delete
+	Components string `mapstructure:"components"`
+}
+
+// Recurring check window recurs on week days
+func (w FreezeWindow) Recurring() bool {
+	return w.Start != "" || w.End != "" || len(w.Days) > 0
+}
+
+// GetFreezeWindows the app.freeze window and the additional app.freeze.windows
+func GetFreezeWindows() ([]FreezeWindow, error) {
+	var (
+		windows    []FreezeWindow
+		message    = viper.GetString("app.freeze.message")
+		components = viper.GetString("app.freeze.components")
+	)
+
+	if viper.GetString("app.freeze.startTime") != "" {
+		windows = append(windows, FreezeWindow{
+			Name:       "default",
+			StartTime:  viper.GetString("app.freeze.startTime"),
+			EndTime:    viper.GetString("app.freeze.endTime"),
+			Timezone:   viper.GetString("app.freeze.timezone"),
+			Message:    message,
+			Components: components,
+		})
+	}
+
+	var recurring []FreezeWindow
+	if err := viper.UnmarshalKey("app.freeze.windows", &recurring); err != nil {
+		return nil, fmt.Errorf("unable to load freeze windows. %s", err.Error())
+	}
+
+	for _, w := range recurring {
+		if w.Message == "" {
+			w.Message = message
+		}
+
+		if w.Components == "" {
+			w.Components = components
+		}
+
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// PriceApprovalAnnotation default annotation approving a price change above the allowed percentage
+const PriceApprovalAnnotation = "product.estore.com/price-change-approved"
+
+// PriceBounds price bounds
+type PriceBounds struct {
+	Min *float64 `mapstructure:"min"`
+	Max *float64 `mapstructure:"max"`
+}
+
+// PricePolicy price governance policy
+type PricePolicy struct {
+	PriceBounds `mapstructure:",squash"`
+	// MaxChangePercent maximum price change in a single update, 0 disables the check
+	MaxChangePercent float64 `mapstructure:"maxChangePercent"`
+	// ApprovalAnnotation annotation set to "true" to approve a bigger change
+	ApprovalAnnotation string `mapstructure:"approvalAnnotation"`
+	// Categories bounds per category
+	Categories map[string]PriceBounds `mapstructure:"categories"`
+}
+
+// PricePolicyEnabled check price governance is configured
+func PricePolicyEnabled() bool {
+	return viper.IsSet("app.price")
+}
+
+// GetPricePolicy price governance policy from app.price
+func GetPricePolicy() (PricePolicy, error) {
+	policy := PricePolicy{}
+
+	if err := viper.UnmarshalKey("app.price", &policy); err != nil {
+		return policy, fmt.Errorf("unable to load price policy. %s", err.Error())
+	}
+
+	if policy.ApprovalAnnotation == "" {
+		policy.ApprovalAnnotation = PriceApprovalAnnotation
+	}
+
+	return policy, nil
+}
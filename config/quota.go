@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// QuotaWildcard quota key applying to every brand or category without its own limit
+const QuotaWildcard = "*"
+
+// Quota product limits of a namespace checked on create, 0 disables a limit
+type Quota struct {
+	// Products maximum number of products in the namespace
+	Products int `mapstructure:"products"`
+	// Brands maximum number of products per lower case brand
+	Brands map[string]int `mapstructure:"brands"`
+	// Categories maximum number of products per category including its children
+	Categories map[string]int `mapstructure:"categories"`
+	// Enforcement enforce, warn or audit, defaults to enforce whatever the namespace enforcement mode
+	Enforcement string `mapstructure:"-"`
+}
+
+// IsZero check no limit is set
+func (q Quota) IsZero() bool {
+	return q.Products <= 0 && len(q.Brands) == 0 && len(q.Categories) == 0
+}
+
+// BrandLimit limit of the brand, the wildcard limit when the brand has none
+func (q Quota) BrandLimit(brand string) int {
+	if limit, ok := q.Brands[strings.ToLower(brand)]; ok {
+		return limit
+	}
+
+	return q.Brands[QuotaWildcard]
+}
+
+// GetQuota quota of the namespace, an entry in app.quotas.namespaces replaces app.quotas.default
+func GetQuota(namespace string) (Quota, error) {
+	var quota Quota
+
+	key := "app.quotas.default"
+	if nsKey := "app.quotas.namespaces." + strings.ToLower(namespace); viper.IsSet(nsKey) {
+		key = nsKey
+	}
+
+	if err := viper.UnmarshalKey(key, &quota); err != nil {
+		return quota, fmt.Errorf("unable to load quota %s. %s", key, err.Error())
+	}
+
+	quota.Enforcement = guardEnforcement(viper.GetString("app.quotas.enforcement"))
+
+	return quota, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Rule declarative product validation rule
+type Rule struct {
+	// Name rule name used in error messages and logs
+	Name string `mapstructure:"name"`
+	// Field path of the product field e.g. spec.brand or metadata.labels[product.estore.com/brand]
+	Field string `mapstructure:"field"`
+	// Operations operations the rule applies to, defaults to CREATE and UPDATE
+	Operations []string `mapstructure:"operations"`
+	// Required field must be present and not empty
+	Required bool `mapstructure:"required"`
+	// Regex field must match the pattern
+	Regex string `mapstructure:"regex"`
+	// NotRegex field must not match the pattern
+	NotRegex string `mapstructure:"notRegex"`
+	// Min minimum value for numbers, minimum length for strings and lists
+	Min *float64 `mapstructure:"min"`
+	// Max maximum value for numbers, maximum length for strings and lists
+	Max *float64 `mapstructure:"max"`
+	// Enum allowed values
+	Enum []string `mapstructure:"enum"`
+	// Message custom message, {name}, {field} and {value} are replaced
+	Message string `mapstructure:"message"`
+	// Enforcement enforce, warn or audit, defaults to the namespace enforcement mode
+	Enforcement string `mapstructure:"enforcement"`
+}
+
+var defaultProductRules = []Rule{
+	{
+		Name:     "name-prefix",
+		Field:    "metadata.name",
+		NotRegex: "^kube-",
+		Message:  "metadata.name {value} with prefix kube- is not allowed",
+	},
+	{
+		Name:     "brand-pattern",
+		Field:    "spec.brand",
+		Required: true,
+		Regex:    "^([a-zA-Z-]+$)",
+		Message:  "spec.brand {value} is not valid",
+	},
+}
+
+// GetProductRules product validation rules from app.rules.product, defaults when not configured
+func GetProductRules() ([]Rule, error) {
+	if !viper.IsSet("app.rules.product") {
+		return defaultProductRules, nil
+	}
+
+	var rules []Rule
+	if err := viper.UnmarshalKey("app.rules.product", &rules); err != nil {
+		return nil, fmt.Errorf("unable to load product rules. %s", err.Error())
+	}
+
+	return rules, nil
+}
+
+// AppliesTo check rule applies to the operation
+func (r Rule) AppliesTo(operation string) bool {
+	operations := r.Operations
+	if len(operations) == 0 {
+		operations = []string{Create, Update}
+	}
+
+	for _, op := range operations {
+		if strings.EqualFold(op, operation) {
+			return true
+		}
+	}
+
+	return false
+}
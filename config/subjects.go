@@ -0,0 +1,82 @@
+package config
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// SubjectListBlacklist requests matching the list are denied
+	SubjectListBlacklist = "blacklist"
+	// SubjectListSystem requests matching the list are allowed without mutation and validation
+	SubjectListSystem = "system"
+	// SubjectListPrivileged requests matching the list are not stopped by freeze windows
+	SubjectListPrivileged = "privileged"
+)
+
+// SubjectList users, groups, service account namespaces and request namespaces of app.<name>, entries are exact
+// or prefix values, globs with * and ? or regular expressions between slashes e.g. /^ci-[0-9]+$/
+type SubjectList struct {
+	// Name list name used in logs
+	Name string
+	// Users user names
+	Users []string
+	// Groups groups of the user
+	Groups []string
+	// ServiceAccountNamespaces namespaces of the service account users
+	ServiceAccountNamespaces []string
+	// Namespaces namespaces of the request
+	Namespaces []string
+	// Exact value entries match the whole value instead of a prefix, set for the lists granting a privilege
+	Exact bool
+}
+
+// GetSubjectList subject list from app.<name>
+func GetSubjectList(name string) SubjectList {
+	return SubjectList{
+		Name:                     name,
+		Users:                    splitList(viper.GetString("app." + name + ".users")),
+		Groups:                   splitList(viper.GetString("app." + name + ".groups")),
+		ServiceAccountNamespaces: splitList(viper.GetString("app." + name + ".serviceAccountNamespaces")),
+		Namespaces:               splitList(viper.GetString("app." + name + ".namespaces")),
+		Exact:                    name == SubjectListPrivileged,
+	}
+}
+
+// IsPattern check the entry is a glob or a regular expression instead of a value
+func IsPattern(entry string) bool {
+	return isRegexEntry(entry) || strings.ContainsAny(entry, "*?[")
+}
+
+// MatchEntry check the value matches the subject list entry, invalid patterns do not match
+func MatchEntry(entry, value string) bool {
+	switch {
+	case entry == "":
+		return false
+	case isRegexEntry(entry):
+		matched, err := regexp.MatchString(entry[1:len(entry)-1], value)
+		return err == nil && matched
+	case IsPattern(entry):
+		matched, err := path.Match(entry, value)
+		return err == nil && matched
+	default:
+		return strings.HasPrefix(value, entry)
+	}
+}
+
+// MatchExactEntry check the value matches the subject list entry, value entries match the whole value and not a
+// prefix so that a privilege is not granted to every value starting with the entry
+func MatchExactEntry(entry, value string) bool {
+	if entry != "" && !IsPattern(entry) {
+		return entry == value
+	}
+
+	return MatchEntry(entry, value)
+}
+
+func isRegexEntry(entry string) bool {
+	return len(entry) > 2 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/")
+}
@@ -4,12 +4,17 @@ require (
 	github.com/arutselvan15/estore-common v1.0.9
 	github.com/arutselvan15/estore-product-kube-client v1.0.5
 	github.com/arutselvan15/go-utils v1.0.7
+	github.com/evanphx/json-patch v4.2.0+incompatible
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/google/uuid v1.1.1
+	github.com/hashicorp/golang-lru v0.5.4 // indirect
+	github.com/prometheus/client_golang v1.5.1
 	github.com/spf13/viper v1.6.2
-	github.com/stretchr/testify v1.3.0
+	github.com/stretchr/testify v1.4.0
 	k8s.io/api v0.17.2
 	k8s.io/apimachinery v0.17.2
 	k8s.io/client-go v11.0.1-0.20190606204521-b8faab9c5193+incompatible
+	sigs.k8s.io/yaml v1.1.0
 )
 
 replace (
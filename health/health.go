@@ -0,0 +1,140 @@
+// Package health provides liveness, readiness and version endpoints
+package health
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// HealthzURL liveness url
+	HealthzURL = "/healthz"
+	// ReadyzURL readiness url
+	ReadyzURL = "/readyz"
+	// VersionURL version url
+	VersionURL = "/version"
+
+	// TLS readiness condition for the loaded certificate and key
+	TLS = "tls"
+	// Clients readiness condition for the estore clients
+	Clients = "clients"
+	// Config readiness condition for the loaded configuration
+	Config = "config"
+	// Cache readiness condition for the synced product cache
+	Cache = "cache"
+	// Serving readiness condition for the webhook server, not ready while shutting down
+	Serving = "serving"
+)
+
+// Health probe state with named readiness conditions
+type Health struct {
+	versionFile string
+
+	mu         sync.RWMutex
+	conditions map[string]bool
+}
+
+// New health with the readiness conditions initially not ready
+func New(versionFile string, conditions ...string) *Health {
+	h := &Health{versionFile: versionFile, conditions: map[string]bool{}}
+	for _, c := range conditions {
+		h.conditions[c] = false
+	}
+
+	return h
+}
+
+// SetReady set a readiness condition
+func (h *Health) SetReady(condition string, ready bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.conditions[condition] = ready
+}
+
+// Ready check all readiness conditions and return the ones not ready
+func (h *Health) Ready() (bool, []string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var notReady []string
+
+	for c, ready := range h.conditions {
+		if !ready {
+			notReady = append(notReady, c)
+		}
+	}
+
+	sort.Strings(notReady)
+
+	return len(notReady) == 0, notReady
+}
+
+// Register register the health endpoints
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.HandleFunc(HealthzURL, h.Healthz)
+	mux.HandleFunc(ReadyzURL, h.Readyz)
+	mux.HandleFunc(VersionURL, h.Version)
+}
+
+// Healthz process is alive
+func (h *Health) Healthz(httpWriter http.ResponseWriter, _ *http.Request) {
+	_, _ = fmt.Fprint(httpWriter, "ok")
+}
+
+// Readyz all readiness conditions are met
+func (h *Health) Readyz(httpWriter http.ResponseWriter, _ *http.Request) {
+	if ready, notReady := h.Ready(); !ready {
+		http.Error(httpWriter, fmt.Sprintf("not ready: %s", strings.Join(notReady, ", ")),
+			http.StatusServiceUnavailable)
+		return
+	}
+
+	_, _ = fmt.Fprint(httpWriter, "ok")
+}
+
+// Version version file generated by make gen-version as json
+func (h *Health) Version(httpWriter http.ResponseWriter, _ *http.Request) {
+	version, err := readVersionFile(h.versionFile)
+	if err != nil {
+		http.Error(httpWriter, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	httpWriter.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(httpWriter).Encode(version); err != nil {
+		http.Error(httpWriter, fmt.Sprintf("can't encode version: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// readVersionFile read the Key=Value lines of the version file
+func readVersionFile(file string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read version file %s: %v", file, err)
+	}
+	defer f.Close()
+
+	version := map[string]string{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+			version[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read version file %s: %v", file, err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth_Readyz(t *testing.T) {
+	h := New("", TLS, Clients, Config)
+	mux := http.NewServeMux()
+	h.Register(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", ReadyzURL, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "not ready: clients, config, tls\n", recorder.Body.String())
+
+	h.SetReady(TLS, true)
+	h.SetReady(Clients, true)
+	h.SetReady(Config, true)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", ReadyzURL, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest("GET", HealthzURL, nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestHealth_Version(t *testing.T) {
+	dir, err := ioutil.TempDir("", "version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	versionFile := filepath.Join(dir, "version.txt")
+	_ = ioutil.WriteFile(versionFile, []byte("Version=v.42\nDate=Mon Feb 17 23:32:14 PST 2020\nHost=builder\n"), 0600)
+
+	tests := []struct {
+		name string
+		file string
+		code int
+		want map[string]string
+	}{
+		{
+			name: "success version", file: versionFile, code: http.StatusOK,
+			want: map[string]string{"Version": "v.42", "Date": "Mon Feb 17 23:32:14 PST 2020", "Host": "builder"},
+		},
+		{name: "failure missing version file", file: filepath.Join(dir, "missing.txt"), code: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			New(tt.file).Version(recorder, httptest.NewRequest("GET", VersionURL, nil))
+
+			assert.Equal(t, tt.code, recorder.Code)
+
+			if tt.want != nil {
+				got := map[string]string{}
+				_ = json.Unmarshal(recorder.Body.Bytes(), &got)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
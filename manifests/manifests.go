@@ -0,0 +1,449 @@
+// Package manifests generates the webhook deployment and registration objects, the webhook configurations
+// exclude namespaces by the kubernetes.io/metadata.name label which requires kubernetes 1.21 or later
+package manifests
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+	"github.com/arutselvan15/estore-product-kube-webhook/health"
+)
+
+const (
+	// NamespaceNameLabel namespace name label set by the api server from kubernetes 1.21, used to exclude
+	// namespaces by name, older clusters do not set it and every namespace is sent to the webhook
+	NamespaceNameLabel = "kubernetes.io/metadata.name"
+
+	productResource = "products"
+	servicePort     = 443
+	certsMountPath  = "/etc/webhook/certs"
+	certsVolume     = "webhook-certs"
+	// configMountPath directory the webhook reads config.yaml from
+	configMountPath = "/etc/viper"
+	configFileName  = "config.yaml"
+	configVolume    = "webhook-config"
+)
+
+// Options webhook manifest options
+type Options struct {
+	Name      string
+	Namespace string
+	Image     string
+	Replicas  int32
+	// Port webhook https container port
+	Port int32
+	// MetricsPort metrics and probes plain http container port
+	MetricsPort int32
+	// TLSSecret secret with tls.crt and tls.key mounted in the webhook container
+	TLSSecret string
+	// CABundle pem encoded ca the api server uses to verify the webhook
+	CABundle       []byte
+	FailurePolicy  string
+	SideEffects    string
+	TimeoutSeconds int32
+	// ExcludeNamespaces namespace names, not prefixes, not sent to the webhook
+	ExcludeNamespaces []string
+	// SystemNamespaces system namespace entries of the config, value entries are excluded by name, the namespaces
+	// they match as prefixes and the glob and regular expression entries are bypassed by the webhook
+	SystemNamespaces []string
+	// BlacklistNamespaces namespace prefixes always sent to the webhook so that they are denied
+	BlacklistNamespaces []string
+	// Register run the webhook with --register and grant it the webhook configurations
+	Register bool
+	// Config config.yaml of the webhook, mounted from a config map so that the webhook runs with the config the
+	// configurations were generated from, the webhook runs without config when empty
+	Config []byte
+}
+
+// MutatingWebhookName name of the mutating webhook
+func (o Options) MutatingWebhookName() string {
+	return fmt.Sprintf("mutate.%s.%s", o.Name, pdtv1.SchemeGroupVersion.Group)
+}
+
+// ValidatingWebhookName name of the validating webhook
+func (o Options) ValidatingWebhookName() string {
+	return fmt.Sprintf("validate.%s.%s", o.Name, pdtv1.SchemeGroupVersion.Group)
+}
+
+// Validate check the options shared by the generated objects
+func (o Options) Validate() error {
+	if o.Name == "" || o.Namespace == "" {
+		return fmt.Errorf("name and namespace are required")
+	}
+
+	failurePolicy := admissionregistrationv1.FailurePolicyType(o.FailurePolicy)
+	if failurePolicy != admissionregistrationv1.Fail && failurePolicy != admissionregistrationv1.Ignore {
+		return fmt.Errorf("invalid failure policy %s, expected %s or %s", o.FailurePolicy,
+			admissionregistrationv1.Fail, admissionregistrationv1.Ignore)
+	}
+
+	sideEffects := admissionregistrationv1.SideEffectClass(o.SideEffects)
+	if sideEffects != admissionregistrationv1.SideEffectClassNone &&
+		sideEffects != admissionregistrationv1.SideEffectClassNoneOnDryRun {
+		return fmt.Errorf("invalid side effects %s, expected %s or %s", o.SideEffects,
+			admissionregistrationv1.SideEffectClassNone, admissionregistrationv1.SideEffectClassNoneOnDryRun)
+	}
+
+	if o.TimeoutSeconds < 1 || o.TimeoutSeconds > 30 {
+		return fmt.Errorf("invalid timeout %d, expected 1 to 30 seconds", o.TimeoutSeconds)
+	}
+
+	return nil
+}
+
+// Generate the service account and its cluster role, the config map, service, deployment and webhook
+// configurations
+func Generate(opts Options) ([]runtime.Object, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	objects := []runtime.Object{
+		ServiceAccount(opts),
+		ClusterRole(opts),
+		ClusterRoleBinding(opts),
+	}
+
+	if len(opts.Config) > 0 {
+		objects = append(objects, ConfigMap(opts))
+	}
+
+	return append(objects,
+		Service(opts),
+		Deployment(opts),
+		MutatingWebhookConfiguration(opts),
+		ValidatingWebhookConfiguration(opts),
+	), nil
+}
+
+// Write the objects as a multi document yaml
+func Write(w io.Writer, objects []runtime.Object) error {
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("can't encode %s: %s", obj.GetObjectKind().GroupVersionKind().Kind, err.Error())
+		}
+
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ServiceAccount service account of the webhook pods
+func ServiceAccount(opts Options) *corev1.ServiceAccount {
+	sa := &corev1.ServiceAccount{ObjectMeta: objectMeta(opts)}
+	sa.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ServiceAccount"))
+
+	return sa
+}
+
+// ClusterRole read access to the products of all namespaces for the product cache, the webhook configurations
+// are granted when the webhook registers them
+func ClusterRole(opts Options) *rbacv1.ClusterRole {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: clusterObjectMeta(opts),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{pdtv1.SchemeGroupVersion.Group},
+			Resources: []string{productResource},
+			Verbs:     []string{"get", "list", "watch"},
+		}},
+	}
+
+	if opts.Register {
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			APIGroups: []string{admissionregistrationv1.GroupName},
+			Resources: []string{"mutatingwebhookconfigurations", "validatingwebhookconfigurations"},
+			Verbs:     []string{"get", "create", "update"},
+		})
+	}
+
+	role.SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"))
+
+	return role
+}
+
+// ClusterRoleBinding binding of the cluster role to the service account
+func ClusterRoleBinding(opts Options) *rbacv1.ClusterRoleBinding {
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: clusterObjectMeta(opts),
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     opts.Name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      opts.Name,
+			Namespace: opts.Namespace,
+		}},
+	}
+	binding.SetGroupVersionKind(rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"))
+
+	return binding
+}
+
+// ConfigMap config map with the config.yaml of the webhook
+func ConfigMap(opts Options) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: objectMeta(opts),
+		Data:       map[string]string{configFileName: string(opts.Config)},
+	}
+	cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+
+	return cm
+}
+
+// Service service in front of the webhook pods
+func Service(opts Options) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: objectMeta(opts),
+		Spec: corev1.ServiceSpec{
+			Selector: labels(opts),
+			Ports: []corev1.ServicePort{{
+				Name:       "https",
+				Port:       servicePort,
+				TargetPort: intstr.FromInt(int(opts.Port)),
+			}},
+		},
+	}
+	svc.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+
+	return svc
+}
+
+// Deployment webhook deployment with the tls secret and the config mounted running as the service account
+func Deployment(opts Options) *appsv1.Deployment {
+	replicas := opts.Replicas
+
+	args := []string{
+		fmt.Sprintf("-port=%d", opts.Port),
+		fmt.Sprintf("-metricsPort=%d", opts.MetricsPort),
+		fmt.Sprintf("-tlsCertFile=%s/%s", certsMountPath, corev1.TLSCertKey),
+		fmt.Sprintf("-tlsKeyFile=%s/%s", certsMountPath, corev1.TLSPrivateKeyKey),
+	}
+
+	// the webhook registers the same configurations as the generated ones
+	if opts.Register {
+		args = append(args, "-register",
+			fmt.Sprintf("-serviceName=%s", opts.Name),
+			fmt.Sprintf("-serviceNamespace=%s", opts.Namespace),
+			fmt.Sprintf("-failurePolicy=%s", opts.FailurePolicy),
+			fmt.Sprintf("-sideEffects=%s", opts.SideEffects),
+			fmt.Sprintf("-timeoutSeconds=%d", opts.TimeoutSeconds))
+
+		if len(opts.ExcludeNamespaces) > 0 {
+			args = append(args, fmt.Sprintf("-excludeNamespaces=%s", strings.Join(opts.ExcludeNamespaces, ",")))
+		}
+	}
+
+	probe := func(path string) *corev1.Probe {
+		return &corev1.Probe{
+			Handler: corev1.Handler{HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromInt(int(opts.MetricsPort)),
+			}},
+		}
+	}
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: objectMeta(opts),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels(opts)},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels(opts)},
+				Spec: corev1.PodSpec{
+					ServiceAccountName: opts.Name,
+					Containers: []corev1.Container{{
+						Name:  opts.Name,
+						Image: opts.Image,
+						Args:  args,
+						Ports: []corev1.ContainerPort{
+							{Name: "https", ContainerPort: opts.Port},
+							{Name: "metrics", ContainerPort: opts.MetricsPort},
+						},
+						Env:            []corev1.EnvVar{{Name: "APP_NAME", Value: opts.Name}},
+						LivenessProbe:  probe(health.HealthzURL),
+						ReadinessProbe: probe(health.ReadyzURL),
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      certsVolume,
+							MountPath: certsMountPath,
+							ReadOnly:  true,
+						}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: certsVolume,
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{SecretName: opts.TLSSecret},
+						},
+					}},
+				},
+			},
+		},
+	}
+	if len(opts.Config) > 0 {
+		pod := &deploy.Spec.Template.Spec
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: configVolume,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{
+					Name: opts.Name,
+				}},
+			},
+		})
+		pod.Containers[0].VolumeMounts = append(pod.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      configVolume,
+			MountPath: configMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	deploy.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+
+	return deploy
+}
+
+// MutatingWebhookConfiguration registration of the mutate path for product create and update
+func MutatingWebhookConfiguration(opts Options) *admissionregistrationv1.MutatingWebhookConfiguration {
+	failurePolicy := admissionregistrationv1.FailurePolicyType(opts.FailurePolicy)
+	sideEffects := admissionregistrationv1.SideEffectClass(opts.SideEffects)
+	timeout := opts.TimeoutSeconds
+
+	mwc := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: clusterObjectMeta(opts),
+		Webhooks: []admissionregistrationv1.MutatingWebhook{{
+			Name:                    opts.MutatingWebhookName(),
+			ClientConfig:            clientConfig(opts, cfg.MutateURL),
+			Rules:                   rules(admissionregistrationv1.Create, admissionregistrationv1.Update),
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			TimeoutSeconds:          &timeout,
+			NamespaceSelector:       namespaceSelector(opts),
+			AdmissionReviewVersions: []string{"v1", "v1beta1"},
+		}},
+	}
+	mwc.SetGroupVersionKind(admissionregistrationv1.SchemeGroupVersion.WithKind("MutatingWebhookConfiguration"))
+
+	return mwc
+}
+
+// ValidatingWebhookConfiguration registration of the validate path for product create, update and delete
+func ValidatingWebhookConfiguration(opts Options) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	failurePolicy := admissionregistrationv1.FailurePolicyType(opts.FailurePolicy)
+	sideEffects := admissionregistrationv1.SideEffectClass(opts.SideEffects)
+	timeout := opts.TimeoutSeconds
+
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: clusterObjectMeta(opts),
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name:         opts.ValidatingWebhookName(),
+			ClientConfig: clientConfig(opts, cfg.ValidateURL),
+			Rules: rules(admissionregistrationv1.Create, admissionregistrationv1.Update,
+				admissionregistrationv1.Delete),
+			FailurePolicy:           &failurePolicy,
+			SideEffects:             &sideEffects,
+			TimeoutSeconds:          &timeout,
+			NamespaceSelector:       namespaceSelector(opts),
+			AdmissionReviewVersions: []string{"v1", "v1beta1"},
+		}},
+	}
+	vwc.SetGroupVersionKind(admissionregistrationv1.SchemeGroupVersion.WithKind("ValidatingWebhookConfiguration"))
+
+	return vwc
+}
+
+func clientConfig(opts Options, path string) admissionregistrationv1.WebhookClientConfig {
+	port := int32(servicePort)
+
+	return admissionregistrationv1.WebhookClientConfig{
+		Service: &admissionregistrationv1.ServiceReference{
+			Namespace: opts.Namespace,
+			Name:      opts.Name,
+			Path:      &path,
+			Port:      &port,
+		},
+		CABundle: opts.CABundle,
+	}
+}
+
+func rules(operations ...admissionregistrationv1.OperationType) []admissionregistrationv1.RuleWithOperations {
+	return []admissionregistrationv1.RuleWithOperations{{
+		Operations: operations,
+		Rule: admissionregistrationv1.Rule{
+			APIGroups:   []string{pdtv1.SchemeGroupVersion.Group},
+			APIVersions: []string{pdtv1.SchemeGroupVersion.Version},
+			Resources:   []string{productResource},
+		},
+	}}
+}
+
+// namespaceSelector exclude the namespaces and the system namespaces by name, the webhook namespace is always
+// excluded so that the webhook can not block its own recovery, black listed namespaces are never excluded
+func namespaceSelector(opts Options) *metav1.LabelSelector {
+	seen := map[string]bool{}
+	excluded := []string{}
+
+	namespaces := append([]string{opts.Namespace}, opts.ExcludeNamespaces...)
+
+	for _, ns := range opts.SystemNamespaces {
+		if !cfg.IsPattern(strings.TrimSpace(ns)) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	for _, ns := range namespaces {
+		ns = strings.TrimSpace(ns)
+		if ns != "" && !seen[ns] && !blacklisted(ns, opts.BlacklistNamespaces) {
+			seen[ns] = true
+			excluded = append(excluded, ns)
+		}
+	}
+
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      NamespaceNameLabel,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   excluded,
+		}},
+	}
+}
+
+// blacklisted namespace matches a black list entry the same way the webhook does
+func blacklisted(namespace string, blacklist []string) bool {
+	for _, entry := range blacklist {
+		if cfg.MatchEntry(strings.TrimSpace(entry), namespace) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func objectMeta(opts Options) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: opts.Name, Namespace: opts.Namespace, Labels: labels(opts)}
+}
+
+// clusterObjectMeta meta of the cluster scoped webhook configurations
+func clusterObjectMeta(opts Options) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: opts.Name, Labels: labels(opts)}
+}
+
+func labels(opts Options) map[string]string {
+	return map[string]string{"app": opts.Name}
+}
@@ -0,0 +1,249 @@
+package manifests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func testOptions() Options {
+	return Options{
+		Name:                "product-webhook",
+		Namespace:           "estore-system",
+		Image:               "product-webhook:v1",
+		Replicas:            2,
+		Port:                8000,
+		MetricsPort:         8080,
+		TLSSecret:           "product-webhook-certs",
+		CABundle:            []byte("ca"),
+		FailurePolicy:       "Fail",
+		SideEffects:         "None",
+		TimeoutSeconds:      10,
+		ExcludeNamespaces:   []string{"kube-system", "virus-lab", "default"},
+		SystemNamespaces:    []string{"kube", "default", "monitoring", "/^ci-.*$/", "tmp-*"},
+		BlacklistNamespaces: []string{"virus"},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(o *Options)
+		wantErr bool
+	}{
+		{name: "success", modify: func(o *Options) {}},
+		{name: "failure missing namespace", modify: func(o *Options) { o.Namespace = "" }, wantErr: true},
+		{name: "failure invalid failure policy", modify: func(o *Options) { o.FailurePolicy = "Retry" }, wantErr: true},
+		{name: "failure invalid side effects", modify: func(o *Options) { o.SideEffects = "Some" }, wantErr: true},
+		{name: "failure invalid timeout", modify: func(o *Options) { o.TimeoutSeconds = 31 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := testOptions()
+			tt.modify(&opts)
+
+			got, err := Generate(opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr {
+				assert.Len(t, got, 7)
+			}
+		})
+	}
+}
+
+func TestGenerate_rbac(t *testing.T) {
+	opts := testOptions()
+
+	objects, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	var (
+		sa      *corev1.ServiceAccount
+		role    *rbacv1.ClusterRole
+		binding *rbacv1.ClusterRoleBinding
+		deploy  *appsv1.Deployment
+	)
+
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *corev1.ServiceAccount:
+			sa = o
+		case *rbacv1.ClusterRole:
+			role = o
+		case *rbacv1.ClusterRoleBinding:
+			binding = o
+		case *appsv1.Deployment:
+			deploy = o
+		}
+	}
+
+	if sa == nil || role == nil || binding == nil || deploy == nil {
+		t.Fatalf("Generate() missing service account, cluster role, binding or deployment in %v", objects)
+	}
+
+	// the product cache lists and watches the products of all namespaces
+	assert.Equal(t, "estore-system", sa.Namespace)
+	assert.Equal(t, []rbacv1.PolicyRule{{
+		APIGroups: []string{"estore.com"},
+		Resources: []string{"products"},
+		Verbs:     []string{"get", "list", "watch"},
+	}}, role.Rules)
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role.Name}, binding.RoleRef)
+	assert.Equal(t, []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: sa.Name, Namespace: sa.Namespace}},
+		binding.Subjects)
+	assert.Equal(t, sa.Name, deploy.Spec.Template.Spec.ServiceAccountName)
+	assert.NotContains(t, deploy.Spec.Template.Spec.Containers[0].Args, "-register")
+
+	// registering the webhook configurations needs access to them
+	opts.Register = true
+
+	role = ClusterRole(opts)
+	if assert.Len(t, role.Rules, 2) {
+		assert.Equal(t, []string{"admissionregistration.k8s.io"}, role.Rules[1].APIGroups)
+		assert.Equal(t, []string{"mutatingwebhookconfigurations", "validatingwebhookconfigurations"},
+			role.Rules[1].Resources)
+		assert.Equal(t, []string{"get", "create", "update"}, role.Rules[1].Verbs)
+	}
+
+	assert.Contains(t, Deployment(opts).Spec.Template.Spec.Containers[0].Args, "-register")
+}
+
+func TestGenerate_config(t *testing.T) {
+	opts := testOptions()
+	opts.Config = []byte("app:\n  name: product-webhook\n")
+
+	objects, err := Generate(opts)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	assert.Len(t, objects, 8)
+
+	var cm *corev1.ConfigMap
+	for _, obj := range objects {
+		if o, ok := obj.(*corev1.ConfigMap); ok {
+			cm = o
+		}
+	}
+
+	if assert.NotNil(t, cm) {
+		assert.Equal(t, "estore-system", cm.Namespace)
+		assert.Equal(t, map[string]string{"config.yaml": string(opts.Config)}, cm.Data)
+	}
+
+	// the webhook reads the config from /etc/viper
+	pod := Deployment(opts).Spec.Template.Spec
+	assert.Contains(t, pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "webhook-config",
+		MountPath: "/etc/viper", ReadOnly: true})
+	assert.Contains(t, pod.Volumes, corev1.Volume{Name: "webhook-config", VolumeSource: corev1.VolumeSource{
+		ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: opts.Name}},
+	}})
+
+	// no config is mounted without one
+	assert.Len(t, Deployment(testOptions()).Spec.Template.Spec.Volumes, 1)
+}
+
+func TestDeployment_register(t *testing.T) {
+	opts := testOptions()
+	opts.Register = true
+	opts.SideEffects = "NoneOnDryRun"
+
+	// the webhook registers the configurations with the generated options
+	assert.Equal(t, []string{
+		"-port=8000",
+		"-metricsPort=8080",
+		"-tlsCertFile=/etc/webhook/certs/tls.crt",
+		"-tlsKeyFile=/etc/webhook/certs/tls.key",
+		"-register",
+		"-serviceName=product-webhook",
+		"-serviceNamespace=estore-system",
+		"-failurePolicy=Fail",
+		"-sideEffects=NoneOnDryRun",
+		"-timeoutSeconds=10",
+		"-excludeNamespaces=kube-system,virus-lab,default",
+	}, Deployment(opts).Spec.Template.Spec.Containers[0].Args)
+}
+
+func TestMutatingWebhookConfiguration(t *testing.T) {
+	got := MutatingWebhookConfiguration(testOptions())
+
+	assert.Empty(t, got.Namespace)
+	assert.Len(t, got.Webhooks, 1)
+
+	wh := got.Webhooks[0]
+	assert.Equal(t, "mutate.product-webhook.estore.com", wh.Name)
+	assert.Equal(t, cfg.MutateURL, *wh.ClientConfig.Service.Path)
+	assert.Equal(t, "estore-system", wh.ClientConfig.Service.Namespace)
+	assert.Equal(t, []byte("ca"), wh.ClientConfig.CABundle)
+	assert.Equal(t, admissionregistrationv1.Fail, *wh.FailurePolicy)
+	assert.Equal(t, admissionregistrationv1.SideEffectClassNone, *wh.SideEffects)
+	assert.Equal(t, int32(10), *wh.TimeoutSeconds)
+	assert.Equal(t, []admissionregistrationv1.OperationType{admissionregistrationv1.Create,
+		admissionregistrationv1.Update}, wh.Rules[0].Operations)
+	assert.Equal(t, []string{"estore.com"}, wh.Rules[0].APIGroups)
+	assert.Equal(t, []string{"v1"}, wh.Rules[0].APIVersions)
+	assert.Equal(t, []string{"products"}, wh.Rules[0].Resources)
+}
+
+func TestValidatingWebhookConfiguration(t *testing.T) {
+	got := ValidatingWebhookConfiguration(testOptions())
+
+	wh := got.Webhooks[0]
+	assert.Equal(t, "validate.product-webhook.estore.com", wh.Name)
+	assert.Equal(t, cfg.ValidateURL, *wh.ClientConfig.Service.Path)
+	assert.Contains(t, wh.Rules[0].Operations, admissionregistrationv1.Delete)
+}
+
+func Test_namespaceSelector(t *testing.T) {
+	got := namespaceSelector(testOptions())
+
+	// the webhook namespace and the system namespace names are excluded, system patterns are left to the webhook
+	// and black listed namespaces are still sent to the webhook
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{
+		Key:      NamespaceNameLabel,
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   []string{"estore-system", "kube-system", "default", "kube", "monitoring"},
+	}}, got.MatchExpressions)
+
+	// black list globs and regular expressions match the same way as in the webhook
+	opts := testOptions()
+	opts.BlacklistNamespaces = []string{"/^kube.*$/", "def*"}
+
+	assert.Equal(t, []string{"estore-system", "virus-lab", "monitoring"},
+		namespaceSelector(opts).MatchExpressions[0].Values)
+}
+
+func TestWrite(t *testing.T) {
+	objects, err := Generate(testOptions())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Write(buf, objects); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	out := buf.String()
+	assert.Equal(t, 7, strings.Count(out, "---\n"))
+
+	for _, kind := range []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Service", "Deployment",
+		"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"} {
+		assert.Contains(t, out, "kind: "+kind+"\n")
+	}
+}
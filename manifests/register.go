@@ -0,0 +1,75 @@
+package manifests
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Register create or update the mutating and validating webhook configurations with the ca bundle of the
+// options, existing configurations are replaced by the generated webhooks. The replicas register concurrently so
+// a configuration created by another replica is updated and conflicting updates are retried
+func Register(client kubernetes.Interface, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	mutating := MutatingWebhookConfiguration(opts)
+	mutatingClient := client.AdmissionregistrationV1().MutatingWebhookConfigurations()
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := mutatingClient.Get(mutating.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err = mutatingClient.Create(mutating); !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+
+			current, err = mutatingClient.Get(mutating.Name, metav1.GetOptions{})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		current.Labels = mutating.Labels
+		current.Webhooks = mutating.Webhooks
+		_, err = mutatingClient.Update(current)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to register mutating webhook configuration %s: %v", mutating.Name, err)
+	}
+
+	validating := ValidatingWebhookConfiguration(opts)
+	validatingClient := client.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := validatingClient.Get(validating.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err = validatingClient.Create(validating); !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+
+			current, err = validatingClient.Get(validating.Name, metav1.GetOptions{})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		current.Labels = validating.Labels
+		current.Webhooks = validating.Webhooks
+		_, err = validatingClient.Update(current)
+
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to register validating webhook configuration %s: %v", validating.Name, err)
+	}
+
+	return nil
+}
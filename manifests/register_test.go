@@ -0,0 +1,132 @@
+package manifests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRegister(t *testing.T) {
+	opts := testOptions()
+
+	stale := MutatingWebhookConfiguration(opts)
+	stale.ResourceVersion = "1"
+	stale.Webhooks[0].ClientConfig.CABundle = []byte("old-ca")
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+	}{
+		{name: "success create"},
+		{name: "success update stale ca bundle", objects: []runtime.Object{stale}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.objects...)
+
+			if err := Register(client, opts); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			mwc, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(opts.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("mutating webhook configuration not found: %v", err)
+			}
+
+			vwc, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(opts.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("validating webhook configuration not found: %v", err)
+			}
+
+			assert.Equal(t, []byte("ca"), mwc.Webhooks[0].ClientConfig.CABundle)
+			assert.Equal(t, []byte("ca"), vwc.Webhooks[0].ClientConfig.CABundle)
+
+			// rotation patches the ca bundle in place
+			rotated := opts
+			rotated.CABundle = []byte("new-ca")
+
+			if err := Register(client, rotated); err != nil {
+				t.Fatalf("Register() rotation error = %v", err)
+			}
+
+			mwc, _ = client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(opts.Name, metav1.GetOptions{})
+			vwc, _ = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(opts.Name, metav1.GetOptions{})
+
+			assert.Equal(t, []byte("new-ca"), mwc.Webhooks[0].ClientConfig.CABundle)
+			assert.Equal(t, []byte("new-ca"), vwc.Webhooks[0].ClientConfig.CABundle)
+			assert.Equal(t, admissionregistrationv1.Fail, *mwc.Webhooks[0].FailurePolicy)
+		})
+	}
+}
+
+func TestRegister_concurrentReplica(t *testing.T) {
+	opts := testOptions()
+	client := fake.NewSimpleClientset()
+
+	// another replica creates the configuration between the get and the create of this one
+	created := false
+	client.PrependReactor("create", "mutatingwebhookconfigurations",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if created {
+				return false, nil, nil
+			}
+
+			created = true
+			other := MutatingWebhookConfiguration(opts)
+			other.Webhooks[0].ClientConfig.CABundle = []byte("other-ca")
+
+			if err := client.Tracker().Add(other); err != nil {
+				return true, nil, err
+			}
+
+			return true, nil, apierrors.NewAlreadyExists(admissionregistrationv1.Resource("mutatingwebhookconfigurations"),
+				opts.Name)
+		})
+
+	// and updates it again before the update of this one
+	conflicts := 0
+	client.PrependReactor("update", "validatingwebhookconfigurations",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if conflicts > 0 {
+				return false, nil, nil
+			}
+
+			conflicts++
+
+			return true, nil, apierrors.NewConflict(admissionregistrationv1.Resource("validatingwebhookconfigurations"),
+				opts.Name, fmt.Errorf("the object has been modified"))
+		})
+
+	if err := client.Tracker().Add(ValidatingWebhookConfiguration(opts)); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.CABundle = []byte("new-ca")
+
+	if err := Register(client, opts); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	mwc, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(opts.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("mutating webhook configuration not found: %v", err)
+	}
+
+	vwc, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(opts.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("validating webhook configuration not found: %v", err)
+	}
+
+	assert.True(t, created)
+	assert.Equal(t, 1, conflicts)
+	assert.Equal(t, []byte("new-ca"), mwc.Webhooks[0].ClientConfig.CABundle)
+	assert.Equal(t, []byte("new-ca"), vwc.Webhooks[0].ClientConfig.CABundle)
+}
@@ -0,0 +1,105 @@
+// Package metrics provides prometheus metrics
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "estore_product_webhook"
+
+	// Allowed request allowed
+	Allowed = "allowed"
+	// Denied request denied
+	Denied = "denied"
+	// Skipped request skipped by the webhook annotation
+	Skipped = "skipped"
+	// Blacklisted request denied for a black listed user or namespace
+	Blacklisted = "blacklisted"
+	// SystemBypass request allowed for a system user or namespace
+	SystemBypass = "system_bypass"
+)
+
+var (
+	admissionRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "admission_requests_total",
+		Help:      "Admission requests by path, operation, namespace and decision.",
+	}, []string{"path", "operation", "namespace", "decision"})
+
+	admissionDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "admission_duration_seconds",
+		Help:      "Admission request serve latency.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"path"})
+
+	admissionRequestSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "admission_request_size_bytes",
+		Help:      "Admission request body size.",
+		Buckets:   prometheus.ExponentialBuckets(512, 2, 10),
+	}, []string{"path"})
+
+	admissionErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "admission_errors_total",
+		Help:      "Admission request decode, encode and write errors by http status code.",
+	}, []string{"code"})
+
+	policyViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "policy_violations_total",
+		Help:      "Validation policy violations by rule, namespace and enforcement mode.",
+	}, []string{"rule", "namespace", "mode"})
+
+	certificateExpiry = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "certificate_expiry_timestamp_seconds",
+		Help:      "Expiry of the serving certificate in unix seconds.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(admissionRequests, admissionDuration, admissionRequestSize, admissionErrors,
+		policyViolations, certificateExpiry)
+}
+
+// Handler metrics http handler
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RecordAdmission count an admission decision
+func RecordAdmission(path, operation, namespace, decision string) {
+	admissionRequests.WithLabelValues(path, operation, namespace, decision).Inc()
+}
+
+// ObserveAdmission record the serve latency and request body size, unknown sizes are negative
+func ObserveAdmission(path string, start time.Time, size int64) {
+	admissionDuration.WithLabelValues(path).Observe(time.Since(start).Seconds())
+
+	if size >= 0 {
+		admissionRequestSize.WithLabelValues(path).Observe(float64(size))
+	}
+}
+
+// RecordError count an admission error
+func RecordError(code int) {
+	admissionErrors.WithLabelValues(strconv.Itoa(code)).Inc()
+}
+
+// RecordViolation count a validation policy violation
+func RecordViolation(rule, namespace, mode string) {
+	policyViolations.WithLabelValues(rule, namespace, mode).Inc()
+}
+
+// SetCertificateExpiry set the serving certificate expiry
+func SetCertificateExpiry(notAfter time.Time) {
+	certificateExpiry.Set(float64(notAfter.Unix()))
+}
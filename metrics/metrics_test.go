@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAdmission(t *testing.T) {
+	RecordAdmission("/validate", "CREATE", "sample-ns", Denied)
+	RecordAdmission("/validate", "CREATE", "sample-ns", Denied)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(
+		admissionRequests.WithLabelValues("/validate", "CREATE", "sample-ns", Denied)))
+}
+
+func TestRecordError(t *testing.T) {
+	RecordError(http.StatusBadRequest)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(admissionErrors.WithLabelValues("400")))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveAdmission("/mutate", time.Now(), 1024)
+	ObserveAdmission("/mutate", time.Now(), -1)
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.Contains(recorder.Body.String(),
+		`estore_product_webhook_admission_request_size_bytes_count{path="/mutate"} 1`))
+	assert.True(t, strings.Contains(recorder.Body.String(),
+		`estore_product_webhook_admission_duration_seconds_count{path="/mutate"} 2`))
+}
@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const admissionReviewKind = "AdmissionReview"
+
+// admissionResponseV1 admission.k8s.io/v1 response with warnings, the vendored k8s.io/api predates the field
+type admissionResponseV1 struct {
+	*admissionv1.AdmissionResponse `json:",inline"`
+	Warnings                       []string `json:"warnings,omitempty"`
+}
+
+// admissionReviewV1 admission.k8s.io/v1 review carrying an admissionResponseV1
+type admissionReviewV1 struct {
+	metav1.TypeMeta `json:",inline"`
+	Response        *admissionResponseV1 `json:"response,omitempty"`
+}
+
+// decodeAdmissionReviewBody decodes admission.k8s.io/v1 and v1beta1 reviews, v1 requests are
+// converted to v1beta1 so that both versions go through the same handle path
+func decodeAdmissionReviewBody(body []byte) (*v1beta1.AdmissionReview, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(body, &typeMeta); err != nil {
+		return nil, fmt.Errorf("can't decode body: %s", err.Error())
+	}
+
+	if typeMeta.APIVersion == admissionv1.SchemeGroupVersion.String() {
+		admissionReviewV1 := &admissionv1.AdmissionReview{}
+		if _, _, err := deserializer.Decode(body, nil, admissionReviewV1); err != nil {
+			return nil, fmt.Errorf("can't decode body: %s", err.Error())
+		}
+
+		return &v1beta1.AdmissionReview{
+			TypeMeta: admissionReviewV1.TypeMeta,
+			Request:  toV1beta1AdmissionRequest(admissionReviewV1.Request),
+		}, nil
+	}
+
+	admissionReviewV1beta1 := &v1beta1.AdmissionReview{}
+	if _, _, err := deserializer.Decode(body, nil, admissionReviewV1beta1); err != nil {
+		return nil, fmt.Errorf("can't decode body: %s", err.Error())
+	}
+
+	return admissionReviewV1beta1, nil
+}
+
+// encodeAdmissionReview encodes the response in the admission review version of the request,
+// unknown versions are answered with v1beta1 which can not carry the warnings so they are only logged
+func encodeAdmissionReview(apiVersion string, response *v1beta1.AdmissionResponse, warnings []string) ([]byte, error) {
+	if apiVersion == admissionv1.SchemeGroupVersion.String() {
+		review := admissionReviewV1{}
+		review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind(admissionReviewKind))
+
+		if response != nil {
+			review.Response = &admissionResponseV1{
+				AdmissionResponse: toV1AdmissionResponse(response),
+				Warnings:          warnings,
+			}
+		}
+
+		return json.Marshal(review)
+	}
+
+	for _, w := range warnings {
+		log.Warnf("admission warning not returned to %s client: %s", v1beta1.SchemeGroupVersion.String(), w)
+	}
+
+	admissionReviewV1beta1 := v1beta1.AdmissionReview{Response: response}
+	admissionReviewV1beta1.SetGroupVersionKind(v1beta1.SchemeGroupVersion.WithKind(admissionReviewKind))
+
+	return json.Marshal(admissionReviewV1beta1)
+}
+
+func toV1beta1AdmissionRequest(req *admissionv1.AdmissionRequest) *v1beta1.AdmissionRequest {
+	if req == nil {
+		return nil
+	}
+
+	return &v1beta1.AdmissionRequest{
+		UID:                req.UID,
+		Kind:               req.Kind,
+		Resource:           req.Resource,
+		SubResource:        req.SubResource,
+		RequestKind:        req.RequestKind,
+		RequestResource:    req.RequestResource,
+		RequestSubResource: req.RequestSubResource,
+		Name:               req.Name,
+		Namespace:          req.Namespace,
+		Operation:          v1beta1.Operation(req.Operation),
+		UserInfo:           req.UserInfo,
+		Object:             req.Object,
+		OldObject:          req.OldObject,
+		DryRun:             req.DryRun,
+		Options:            req.Options,
+	}
+}
+
+func toV1AdmissionResponse(resp *v1beta1.AdmissionResponse) *admissionv1.AdmissionResponse {
+	if resp == nil {
+		return nil
+	}
+
+	respV1 := &admissionv1.AdmissionResponse{
+		UID:              resp.UID,
+		Allowed:          resp.Allowed,
+		Result:           resp.Result,
+		Patch:            resp.Patch,
+		AuditAnnotations: resp.AuditAnnotations,
+	}
+
+	if resp.PatchType != nil {
+		pt := admissionv1.PatchType(*resp.PatchType)
+		respV1.PatchType = &pt
+	}
+
+	return respV1
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/api/admission/v1beta1"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+	cc "github.com/arutselvan15/estore-common/config"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+const (
+	sampleCreateFile = "../docs/sample-create.json"
+	sampleDeleteFile = "../docs/sample-delete.json"
+)
+
+// loadSampleReview loads a docs sample admission review in the given api version
+func loadSampleReview(t *testing.T, file, apiVersion string) []byte {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unable to read sample %s: %v", file, err)
+	}
+
+	return bytes.Replace(data, []byte(`"apiVersion": "admission.k8s.io/v1beta1"`),
+		[]byte(`"apiVersion": "`+apiVersion+`"`), 1)
+}
+
+func Test_decodeAdmissionReviewBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		apiVersion string
+		operation  v1beta1.Operation
+	}{
+		{name: "success v1beta1 create", file: sampleCreateFile, apiVersion: "admission.k8s.io/v1beta1", operation: v1beta1.Create},
+		{name: "success v1beta1 delete", file: sampleDeleteFile, apiVersion: "admission.k8s.io/v1beta1", operation: v1beta1.Delete},
+		{name: "success v1 create", file: sampleCreateFile, apiVersion: "admission.k8s.io/v1", operation: v1beta1.Create},
+		{name: "success v1 delete", file: sampleDeleteFile, apiVersion: "admission.k8s.io/v1", operation: v1beta1.Delete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAdmissionReviewBody(loadSampleReview(t, tt.file, tt.apiVersion))
+			if err != nil {
+				t.Fatalf("decodeAdmissionReviewBody() error = %v", err)
+			}
+
+			assert.Equal(t, tt.apiVersion, got.APIVersion)
+			assert.Equal(t, tt.operation, got.Request.Operation)
+			assert.Equal(t, "examples-product-1", got.Request.Name)
+			assert.Equal(t, "estore-infra", got.Request.Namespace)
+		})
+	}
+}
+
+func Test_encodeAdmissionReview(t *testing.T) {
+	pt := v1beta1.PatchTypeJSONPatch
+	resp := &v1beta1.AdmissionResponse{UID: genUUID(), Allowed: true, Patch: []byte(`[]`), PatchType: &pt}
+
+	tests := []struct {
+		name       string
+		apiVersion string
+		want       string
+	}{
+		{name: "success v1", apiVersion: "admission.k8s.io/v1", want: "admission.k8s.io/v1"},
+		{name: "success v1beta1", apiVersion: "admission.k8s.io/v1beta1", want: "admission.k8s.io/v1beta1"},
+		{name: "success unknown version defaults to v1beta1", apiVersion: "", want: "admission.k8s.io/v1beta1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encodeAdmissionReview(tt.apiVersion, resp, nil)
+			if err != nil {
+				t.Fatalf("encodeAdmissionReview() error = %v", err)
+			}
+
+			got := admissionv1.AdmissionReview{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("encodeAdmissionReview() invalid json = %v", err)
+			}
+
+			assert.Equal(t, tt.want, got.APIVersion)
+			assert.Equal(t, admissionReviewKind, got.Kind)
+			assert.Equal(t, resp.UID, got.Response.UID)
+			assert.Equal(t, admissionv1.PatchTypeJSONPatch, *got.Response.PatchType)
+		})
+	}
+}
+
+func TestServer_Serve_admissionVersions(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	tests := []struct {
+		name       string
+		file       string
+		apiVersion string
+		reqPath    string
+		user       string
+		allowed    bool
+		patched    bool
+	}{
+		{name: "success v1beta1 mutate create", file: sampleCreateFile, apiVersion: "admission.k8s.io/v1beta1", reqPath: cfg.MutateURL, user: "testuser", allowed: true, patched: true},
+		{name: "success v1 mutate create", file: sampleCreateFile, apiVersion: "admission.k8s.io/v1", reqPath: cfg.MutateURL, user: "testuser", allowed: true, patched: true},
+		{name: "success v1beta1 validate delete", file: sampleDeleteFile, apiVersion: "admission.k8s.io/v1beta1", reqPath: cfg.ValidateURL, user: "testuser", allowed: true},
+		{name: "success v1 validate delete", file: sampleDeleteFile, apiVersion: "admission.k8s.io/v1", reqPath: cfg.ValidateURL, user: "testuser", allowed: true},
+		{name: "failure v1 black list user", file: sampleCreateFile, apiVersion: "admission.k8s.io/v1", reqPath: cfg.ValidateURL, user: "stranger", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := loadSampleReview(t, tt.file, tt.apiVersion)
+			body = []byte(strings.Replace(string(body),
+				"system:serviceaccount:kubernetes-dashboard:kubernetes-dashboard", tt.user, 1))
+
+			recorder := httptest.NewRecorder()
+			request, _ := http.NewRequest("POST", tt.reqPath, bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+
+			s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+			s.Serve(recorder, request)
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+
+			got := admissionv1.AdmissionReview{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatalf("serve() invalid response = %v", err)
+			}
+
+			assert.Equal(t, tt.apiVersion, got.APIVersion)
+			assert.Equal(t, admissionReviewKind, got.Kind)
+			assert.Equal(t, "77488c18-9a28-4434-8b0f-4a1550d5ff58", string(got.Response.UID))
+			assert.Equal(t, tt.allowed, got.Response.Allowed, got.Response.Result)
+			assert.Equal(t, tt.patched, got.Response.Patch != nil)
+		})
+	}
+}
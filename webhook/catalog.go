@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	cc "github.com/arutselvan15/estore-common/clients"
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+)
+
+// ProductLister read access to the existing products for validations across products, implemented by the
+// informer backed catalog.Cache
+type ProductLister interface {
+	// List products of the namespace, all namespaces for metav1.NamespaceAll
+	List(namespace string) ([]*pdtv1.Product, error)
+	// ListByBrand products of the brand in all namespaces, case insensitive
+	ListByBrand(brand string) ([]*pdtv1.Product, error)
+	// ListByLabel products with the label value in all namespaces
+	ListByLabel(key, value string) ([]*pdtv1.Product, error)
+}
+
+// products lister of the server, the api server is queried when no cache is set
+func (s Server) products() ProductLister {
+	if s.Products != nil {
+		return s.Products
+	}
+
+	if s.Clients != nil {
+		return clientLister{clients: s.Clients}
+	}
+
+	return nil
+}
+
+// clientLister ProductLister with live calls, used by the review command and tests without a cache
+type clientLister struct {
+	clients cc.EstoreClientInterface
+}
+
+func (l clientLister) List(namespace string) ([]*pdtv1.Product, error) {
+	return l.list(namespace, metav1.ListOptions{})
+}
+
+func (l clientLister) ListByBrand(brand string) ([]*pdtv1.Product, error) {
+	products, err := l.list(metav1.NamespaceAll, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pdtv1.Product
+
+	for _, pdt := range products {
+		if strings.EqualFold(pdt.Spec.Brand, brand) {
+			result = append(result, pdt)
+		}
+	}
+
+	return result, nil
+}
+
+func (l clientLister) ListByLabel(key, value string) ([]*pdtv1.Product, error) {
+	return l.list(metav1.NamespaceAll, metav1.ListOptions{LabelSelector: labels.Set{key: value}.String()})
+}
+
+func (l clientLister) list(namespace string, opts metav1.ListOptions) ([]*pdtv1.Product, error) {
+	list, err := l.clients.GetProductClient().EstoreV1().Products(namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*pdtv1.Product, 0, len(list.Items))
+	for i := range list.Items {
+		products = append(products, &list.Items[i])
+	}
+
+	return products, nil
+}
@@ -0,0 +1,94 @@
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+const categorySeparator = "/"
+
+// validateCategories check the categories against the taxonomy, duplicates, empty entries and count
+func validateCategories(categories []string) []string {
+	var (
+		errors []string
+		policy = cfg.GetCategoryPolicy()
+		seen   = map[string]bool{}
+	)
+
+	for _, c := range categories {
+		category := normalizeCategory(c)
+
+		if category == "" {
+			errors = append(errors, "spec.categories must not contain empty entries")
+			continue
+		}
+
+		if seen[category] {
+			errors = append(errors, fmt.Sprintf("spec.categories %s is duplicated", c))
+			continue
+		}
+
+		seen[category] = true
+
+		if len(policy.Taxonomy) > 0 && !categoryInTaxonomy(category, policy.Taxonomy) {
+			errors = append(errors, fmt.Sprintf("spec.categories %s is not in the catalog taxonomy", c))
+		}
+	}
+
+	if policy.Min > 0 && len(categories) < policy.Min {
+		errors = append(errors, fmt.Sprintf("spec.categories count %d is less than minimum %d",
+			len(categories), policy.Min))
+	}
+
+	if policy.Max > 0 && len(categories) > policy.Max {
+		errors = append(errors, fmt.Sprintf("spec.categories count %d is greater than maximum %d",
+			len(categories), policy.Max))
+	}
+
+	return errors
+}
+
+// categoryInTaxonomy category or one of its children is in the taxonomy
+func categoryInTaxonomy(category string, taxonomy []string) bool {
+	for _, t := range taxonomy {
+		t = normalizeCategory(t)
+		if t == category || strings.HasPrefix(t, category+categorySeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeCategories lower cased and sorted categories, unchanged when normalization is disabled
+func normalizeCategories(categories []string) []string {
+	if len(categories) == 0 || !cfg.GetCategoryNormalize() {
+		return categories
+	}
+
+	normalized := make([]string, 0, len(categories))
+	for _, c := range categories {
+		normalized = append(normalized, normalizeCategory(c))
+	}
+
+	sort.Strings(normalized)
+
+	return normalized
+}
+
+// categoryWithin category is the parent or one of its children, both normalized
+func categoryWithin(category, parent string) bool {
+	return category == parent || strings.HasPrefix(category, parent+categorySeparator)
+}
+
+func normalizeCategory(category string) string {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(category)), categorySeparator)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	return strings.Join(parts, categorySeparator)
+}
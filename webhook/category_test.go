@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func Test_validateCategories(t *testing.T) {
+	viper.Set("app.categories.taxonomy", "cellphones, electronics/cellphones, home/kitchen")
+	viper.Set("app.categories.min", 1)
+	viper.Set("app.categories.max", 3)
+
+	defer viper.Set("app.categories.taxonomy", nil)
+	defer viper.Set("app.categories.min", nil)
+	defer viper.Set("app.categories.max", nil)
+
+	tests := []struct {
+		name       string
+		categories []string
+		want       []string
+	}{
+		{name: "success valid categories", categories: []string{"cellphones", "electronics/cellphones"}},
+		{name: "success parent category", categories: []string{"electronics"}},
+		{name: "success case insensitive", categories: []string{"Home/Kitchen"}},
+		{name: "failure unknown category", categories: []string{"toys"}, want: []string{"spec.categories toys is not in the catalog taxonomy"}},
+		{name: "failure unknown child category", categories: []string{"home/garden"}, want: []string{"spec.categories home/garden is not in the catalog taxonomy"}},
+		{name: "failure empty entry", categories: []string{"cellphones", " "}, want: []string{"spec.categories must not contain empty entries"}},
+		{name: "failure duplicate", categories: []string{"cellphones", "CellPhones"}, want: []string{"spec.categories CellPhones is duplicated"}},
+		{name: "failure too few", categories: nil, want: []string{"spec.categories count 0 is less than minimum 1"}},
+		{
+			name: "failure too many", categories: []string{"cellphones", "electronics", "home", "home/kitchen"},
+			want: []string{"spec.categories count 4 is greater than maximum 3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateCategories(tt.categories); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateCategories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_normalizeCategories(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []string
+		want       []string
+	}{
+		{name: "success no categories", categories: nil},
+		{name: "success already normalized", categories: []string{"cellphones", "electronics/cellphones"}, want: []string{"cellphones", "electronics/cellphones"}},
+		{name: "success normalize case and order", categories: []string{"Home/Kitchen", " cellphones"}, want: []string{"cellphones", "home/kitchen"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeCategories(tt.categories); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeCategories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
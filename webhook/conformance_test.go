@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+	cc "github.com/arutselvan15/estore-common/config"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+const (
+	conformanceDir = "testdata/conformance"
+	goldenSuffix   = ".golden.json"
+)
+
+var update = flag.Bool("update", false, "update the conformance golden files")
+
+// conformanceGolden expected admission response of a conformance case
+type conformanceGolden struct {
+	Allowed  bool                     `json:"allowed"`
+	Message  string                   `json:"message,omitempty"`
+	Warnings []string                 `json:"warnings,omitempty"`
+	Patch    []map[string]interface{} `json:"patch,omitempty"`
+}
+
+// TestConformance review every request in testdata/conformance/mutate and testdata/conformance/validate and
+// compare the response with the golden file next to it, go test ./webhook -run TestConformance -update
+// regenerates the golden files
+func TestConformance(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	// friday 2020-02-21 12:00 utc
+	timeNow = func() time.Time { return time.Date(2020, 2, 21, 12, 0, 0, 0, time.UTC) }
+
+	defer func() { timeNow = time.Now }()
+
+	for reqPath, dir := range map[string]string{cfg.MutateURL: "mutate", cfg.ValidateURL: "validate"} {
+		files, err := ioutil.ReadDir(filepath.Join(conformanceDir, dir))
+		if err != nil {
+			t.Fatalf("unable to read conformance cases: %v", err)
+		}
+
+		for _, f := range files {
+			if f.IsDir() || !isConformanceRequest(f.Name()) {
+				continue
+			}
+
+			file := filepath.Join(conformanceDir, dir, f.Name())
+
+			t.Run(filepath.Join(dir, f.Name()), func(t *testing.T) {
+				runConformanceCase(t, reqPath, file)
+			})
+		}
+	}
+}
+
+func isConformanceRequest(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml" || (ext == ".json" && !strings.HasSuffix(name, goldenSuffix))
+}
+
+func runConformanceCase(t *testing.T, reqPath, file string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", file, err)
+	}
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+
+	result, err := s.Review(reqPath, data)
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+
+	got, err := newConformanceGolden(result)
+	if err != nil {
+		t.Fatalf("invalid review result: %v", err)
+	}
+
+	goldenFile := strings.TrimSuffix(file, filepath.Ext(file)) + goldenSuffix
+
+	if *update {
+		out, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatalf("unable to encode golden: %v", err)
+		}
+
+		if err := ioutil.WriteFile(goldenFile, append(out, '\n'), 0644); err != nil {
+			t.Fatalf("unable to write %s: %v", goldenFile, err)
+		}
+
+		return
+	}
+
+	golden, err := ioutil.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("unable to read %s, run with -update to create it: %v", goldenFile, err)
+	}
+
+	want := conformanceGolden{}
+	if err := json.Unmarshal(golden, &want); err != nil {
+		t.Fatalf("invalid golden %s: %v", goldenFile, err)
+	}
+
+	// compare through json so that numbers decode the same way on both sides
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	assert.JSONEq(t, string(wantJSON), string(gotJSON))
+}
+
+// newConformanceGolden golden of a review result, patch operations are sorted as their order is not stable
+func newConformanceGolden(result *ReviewResult) (conformanceGolden, error) {
+	golden := conformanceGolden{Allowed: result.Allowed, Message: result.Message, Warnings: result.Warnings}
+
+	if len(result.Patch) > 0 {
+		if err := json.Unmarshal(result.Patch, &golden.Patch); err != nil {
+			return golden, fmt.Errorf("can't decode patch: %s", err.Error())
+		}
+
+		sort.SliceStable(golden.Patch, func(i, j int) bool {
+			pi, pj := fmt.Sprint(golden.Patch[i]["path"]), fmt.Sprint(golden.Patch[j]["path"])
+			if pi != pj {
+				return pi < pj
+			}
+
+			return fmt.Sprint(golden.Patch[i]["op"]) < fmt.Sprint(golden.Patch[j]["op"])
+		})
+	}
+
+	return golden, nil
+}
@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// validateProtection reject deleting a protected product and removing the protection with an update, the
+// protection is checked even when the product opts out of the validation
+func validateProtection(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation string,
+	userInfo authenticationv1.UserInfo) []violation {
+	switch {
+	case strings.EqualFold(operation, cfg.Delete):
+		return validateDeletion(pdt, userInfo)
+	case strings.EqualFold(operation, cfg.Update) && oldPdt != nil:
+		return validateProtectionRemoval(pdt, *oldPdt, userInfo)
+	default:
+		return nil
+	}
+}
+
+// validateDeletion reject deleting a product protected by the annotation or a label selector unless the user
+// belongs to one of the allowed groups
+func validateDeletion(pdt pdtv1.Product, userInfo authenticationv1.UserInfo) []violation {
+	policy, err := cfg.GetDeletionPolicy()
+	if err != nil {
+		return newViolations("delete-protection", cfg.EnforcementEnforce, "", metav1.CauseTypeUnexpectedServerResponse,
+			[]string{err.Error()})
+	}
+
+	rule, field, err := deletionProtection(pdt, policy)
+	if err != nil {
+		return newViolations("delete-protection", cfg.EnforcementEnforce, "", metav1.CauseTypeUnexpectedServerResponse,
+			[]string{err.Error()})
+	}
+
+	if rule == "" {
+		return nil
+	}
+
+	if userInGroups(userInfo, policy.AllowedGroups) {
+		log.Infof("protected product %s/%s deleted by user %s, protected by %s", pdt.Namespace, pdt.Name,
+			userInfo.Username, rule)
+		return nil
+	}
+
+	message := allowedGroupsMessage(fmt.Sprintf("product %s is protected from deletion by %s", pdt.Name, rule),
+		"delete it", policy.AllowedGroups)
+
+	return newViolations("delete-protection", cfg.EnforcementEnforce, field, metav1.CauseTypeFieldValueInvalid, []string{message})
+}
+
+// validateProtectionRemoval reject an update that removes the annotation or changes the labels so that a protected
+// product is no longer protected unless the user belongs to one of the allowed groups
+func validateProtectionRemoval(pdt, oldPdt pdtv1.Product, userInfo authenticationv1.UserInfo) []violation {
+	policy, err := cfg.GetDeletionPolicy()
+	if err != nil {
+		return newViolations("delete-protection", cfg.EnforcementEnforce, "", metav1.CauseTypeUnexpectedServerResponse,
+			[]string{err.Error()})
+	}
+
+	oldRule, field, err := deletionProtection(oldPdt, policy)
+	if err != nil {
+		return newViolations("delete-protection", cfg.EnforcementEnforce, "", metav1.CauseTypeUnexpectedServerResponse,
+			[]string{err.Error()})
+	}
+
+	if oldRule == "" {
+		return nil
+	}
+
+	// the update may keep the product protected by another rule
+	if rule, _, _ := deletionProtection(pdt, policy); rule != "" {
+		return nil
+	}
+
+	if userInGroups(userInfo, policy.AllowedGroups) {
+		log.Infof("protection of product %s/%s by %s removed by user %s", pdt.Namespace, pdt.Name, oldRule,
+			userInfo.Username)
+		return nil
+	}
+
+	message := allowedGroupsMessage(fmt.Sprintf("product %s is protected from deletion by %s, the protection "+
+		"can not be removed", pdt.Name, oldRule), "remove it", policy.AllowedGroups)
+
+	return newViolations("delete-protection", cfg.EnforcementEnforce, field, metav1.CauseTypeFieldValueInvalid, []string{message})
+}
+
+// allowedGroupsMessage message with the groups allowed to do the action, unchanged when no group is allowed
+func allowedGroupsMessage(message, action string, groups []string) string {
+	if len(groups) == 0 {
+		return message
+	}
+
+	return fmt.Sprintf("%s, only users of groups %s can %s", message, strings.Join(groups, ", "), action)
+}
+
+// deletionProtection the rule protecting the product and its field, empty when the product is not protected
+func deletionProtection(pdt pdtv1.Product, policy cfg.DeletionPolicy) (string, string, error) {
+	if strings.EqualFold(pdt.GetAnnotations()[policy.ProtectAnnotation], "true") {
+		return fmt.Sprintf("annotation %s", policy.ProtectAnnotation),
+			fmt.Sprintf("metadata.annotations[%s]", policy.ProtectAnnotation), nil
+	}
+
+	for _, s := range policy.Selectors {
+		selector, err := labels.Parse(s.Selector)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to parse deletion selector %s. %s", s.Name, err.Error())
+		}
+
+		if !selector.Empty() && selector.Matches(labels.Set(pdt.GetLabels())) {
+			return fmt.Sprintf("rule %s (%s)", s.Name, selector.String()), "metadata.labels", nil
+		}
+	}
+
+	return "", "", nil
+}
@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+)
+
+func Test_validateDeletion(t *testing.T) {
+	viper.Set("app.deletion", map[string]interface{}{
+		"selectors": []interface{}{
+			map[string]interface{}{"name": "flagship", "selector": "tier=flagship"},
+			map[string]interface{}{"name": "launch", "selector": "stage in (launch, preorder)"},
+		},
+		"allowedGroups": "system:masters, estore:admins",
+	})
+	defer viper.Set("app.deletion", nil)
+
+	user := authenticationv1.UserInfo{Username: "testuser", Groups: []string{"system:authenticated"}}
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:authenticated", "estore:admins"}}
+
+	pdt := createProduct("sample-ns", "sample-prd", "apple")
+	annotated := pdt.DeepCopy()
+	annotated.Annotations["estore.com/protect-delete"] = "True"
+	flagship := pdt.DeepCopy()
+	flagship.Labels["tier"] = "flagship"
+	preorder := pdt.DeepCopy()
+	preorder.Labels["stage"] = "preorder"
+
+	tests := []struct {
+		name      string
+		pdt       *pdtv1.Product
+		userInfo  authenticationv1.UserInfo
+		want      []string
+		wantField string
+	}{
+		{name: "success not protected", pdt: pdt, userInfo: user},
+		{
+			name: "failure protected by annotation", pdt: annotated, userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by annotation estore.com/protect-delete, " +
+				"only users of groups system:masters, estore:admins can delete it"},
+			wantField: "metadata.annotations[estore.com/protect-delete]",
+		},
+		{
+			name: "failure protected by label selector", pdt: flagship, userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by rule flagship (tier=flagship), " +
+				"only users of groups system:masters, estore:admins can delete it"},
+			wantField: "metadata.labels",
+		},
+		{
+			name: "failure protected by set based selector", pdt: preorder, userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by rule launch (stage in (launch,preorder)), " +
+				"only users of groups system:masters, estore:admins can delete it"},
+			wantField: "metadata.labels",
+		},
+		{name: "success allowed group", pdt: flagship, userInfo: admin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateDeletion(*tt.pdt, tt.userInfo)
+			assert.Equal(t, tt.want, violationMessages(got))
+
+			for _, v := range got {
+				assert.Equal(t, tt.wantField, v.field)
+			}
+		})
+	}
+}
+
+func Test_validateProtection(t *testing.T) {
+	viper.Set("app.deletion", map[string]interface{}{
+		"selectors": []interface{}{
+			map[string]interface{}{"name": "flagship", "selector": "tier=flagship"},
+		},
+		"allowedGroups": "system:masters, estore:admins",
+	})
+	defer viper.Set("app.deletion", nil)
+
+	user := authenticationv1.UserInfo{Username: "testuser", Groups: []string{"system:authenticated"}}
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:authenticated", "estore:admins"}}
+
+	pdt := createProduct("sample-ns", "sample-prd", "apple")
+	annotated := pdt.DeepCopy()
+	annotated.Annotations["estore.com/protect-delete"] = "true"
+	flagship := pdt.DeepCopy()
+	flagship.Labels["tier"] = "flagship"
+	both := flagship.DeepCopy()
+	both.Annotations["estore.com/protect-delete"] = "true"
+	unprotected := annotated.DeepCopy()
+	unprotected.Annotations["estore.com/protect-delete"] = "false"
+
+	tests := []struct {
+		name      string
+		operation string
+		pdt       *pdtv1.Product
+		oldPdt    *pdtv1.Product
+		userInfo  authenticationv1.UserInfo
+		want      []string
+	}{
+		{name: "success create", operation: "CREATE", pdt: pdt, userInfo: user},
+		{
+			name: "failure delete protected", operation: "DELETE", pdt: flagship, userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by rule flagship (tier=flagship), " +
+				"only users of groups system:masters, estore:admins can delete it"},
+		},
+		{name: "success update not protected", operation: "UPDATE", pdt: flagship, oldPdt: pdt, userInfo: user},
+		{name: "success update keeps protection", operation: "UPDATE", pdt: annotated, oldPdt: annotated, userInfo: user},
+		{
+			name: "failure update removes annotation", operation: "UPDATE", pdt: pdt, oldPdt: annotated, userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by annotation estore.com/protect-delete, " +
+				"the protection can not be removed, only users of groups system:masters, estore:admins can remove it"},
+		},
+		{
+			name: "failure update disables annotation", operation: "UPDATE", pdt: unprotected, oldPdt: annotated,
+			userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by annotation estore.com/protect-delete, " +
+				"the protection can not be removed, only users of groups system:masters, estore:admins can remove it"},
+		},
+		{
+			name: "failure update relabels out of selector", operation: "UPDATE", pdt: pdt, oldPdt: flagship,
+			userInfo: user,
+			want: []string{"product sample-prd is protected from deletion by rule flagship (tier=flagship), " +
+				"the protection can not be removed, only users of groups system:masters, estore:admins can remove it"},
+		},
+		{name: "success update still protected by another rule", operation: "UPDATE", pdt: flagship, oldPdt: both,
+			userInfo: user},
+		{name: "success update allowed group", operation: "UPDATE", pdt: pdt, oldPdt: flagship, userInfo: admin},
+		{name: "success update without old product", operation: "UPDATE", pdt: pdt, userInfo: user},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateProtection(*tt.pdt, tt.oldPdt, tt.operation, tt.userInfo)
+			assert.Equal(t, tt.want, violationMessages(got))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lc "github.com/arutselvan15/go-utils/logconstants"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// findDuplicates existing products in the duplicate scope with the same identity key as the product, an update is
+// only checked when it changes the identity key so that existing duplicates can still be updated and deleted, a
+// failed lookup is logged and not reported so that the catalog is not blocked by the api server
+func (s Server) findDuplicates(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation string) []violation {
+	policy := cfg.GetDuplicatePolicy()
+	lister := s.products()
+
+	if lister == nil || len(policy.Keys) == 0 || strings.EqualFold(operation, cfg.Delete) {
+		return nil
+	}
+
+	key, ok := identityKey(pdt, policy.Keys)
+	if !ok {
+		return nil
+	}
+
+	if strings.EqualFold(operation, cfg.Update) && oldPdt != nil {
+		if oldKey, ok := identityKey(*oldPdt, policy.Keys); ok && oldKey == key {
+			return nil
+		}
+	}
+
+	candidates, err := duplicateCandidates(lister, pdt, policy)
+	if err != nil {
+		log.SetStepState(lc.Error).Errorf("duplicate check skipped, unable to list products. %s", err.Error())
+		return nil
+	}
+
+	var violations []violation
+
+	for _, existing := range candidates {
+		// an update finds the product itself
+		if existing.Namespace == pdt.Namespace && existing.Name == pdt.Name {
+			continue
+		}
+
+		if policy.Scope == cfg.DuplicateScopeNamespace && existing.Namespace != pdt.Namespace {
+			continue
+		}
+
+		if existingKey, ok := identityKey(*existing, policy.Keys); ok && existingKey == key {
+			violations = append(violations, violation{
+				rule:      "duplicate",
+				mode:      policy.Enforcement,
+				field:     policy.Keys[0],
+				causeType: metav1.CauseTypeFieldValueDuplicate,
+				message: fmt.Sprintf("product with the same %s already exists as %s/%s",
+					strings.Join(policy.Keys, ", "), existing.Namespace, existing.Name),
+			})
+		}
+	}
+
+	return violations
+}
+
+// duplicateCandidates products which may share the identity key, narrowed by the label or brand index when
+// the key has one of them
+func duplicateCandidates(lister ProductLister, pdt pdtv1.Product, policy cfg.DuplicatePolicy) ([]*pdtv1.Product,
+	error) {
+	for _, k := range policy.Keys {
+		segments := splitFieldPath(k)
+
+		if len(segments) == 3 && segments[0] == "metadata" && segments[1] == "labels" {
+			if value, found := pdt.Labels[segments[2]]; found {
+				return lister.ListByLabel(segments[2], value)
+			}
+		}
+	}
+
+	for _, k := range policy.Keys {
+		if k == "spec.brand" {
+			return lister.ListByBrand(pdt.Spec.Brand)
+		}
+	}
+
+	if policy.Scope == cfg.DuplicateScopeCluster {
+		return lister.List(metav1.NamespaceAll)
+	}
+
+	return lister.List(pdt.Namespace)
+}
+
+// identityKey case insensitive values of the key fields, products missing any of the fields have no identity
+func identityKey(pdt pdtv1.Product, keys []string) (string, bool) {
+	obj, err := toUnstructured(pdt)
+	if err != nil {
+		return "", false
+	}
+
+	values := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		value, found := lookupField(obj, k)
+		if !found || isEmptyValue(value) {
+			return "", false
+		}
+
+		values = append(values, strings.ToLower(strings.TrimSpace(strings.Join(valueItems(value), ","))))
+	}
+
+	return strings.Join(values, "\x00"), true
+}
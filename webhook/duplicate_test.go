@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+	pdtFake "github.com/arutselvan15/estore-product-kube-client/pkg/client/clientset/versioned/fake"
+
+	"github.com/arutselvan15/estore-product-kube-webhook/catalog"
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func TestServer_findDuplicates(t *testing.T) {
+	defer viper.Set("app.duplicates", nil)
+
+	product := func(namespace, name, displayName, sku string) *pdtv1.Product {
+		pdt := createProduct(namespace, name, "apple")
+		pdt.Spec.DisplayName = displayName
+
+		if sku != "" {
+			pdt.Labels["sku"] = sku
+		}
+
+		return pdt
+	}
+
+	existing := []runtime.Object{
+		product("store-a", "iphone", "iPhone 11", "A2111"),
+		product("store-b", "iphone-eleven", "iPhone 11", "A2111"),
+	}
+
+	tests := []struct {
+		name      string
+		keys      string
+		scope     string
+		pdt       *pdtv1.Product
+		oldPdt    *pdtv1.Product
+		operation string
+		want      []string
+	}{
+		{
+			name: "success unique", keys: "spec.brand, spec.displayName", pdt: product("store-a", "ipad", "iPad", ""),
+			operation: cfg.Create,
+		},
+		{
+			name: "failure same display name in namespace", keys: "spec.brand, spec.displayName",
+			pdt: product("store-a", "iphone-11", " IPHONE 11", ""), operation: cfg.Create,
+			want: []string{"product with the same spec.brand, spec.displayName already exists as store-a/iphone"},
+		},
+		{
+			name: "success other namespace not in scope", keys: "spec.brand, spec.displayName",
+			pdt: product("store-c", "iphone", "iPhone 11", ""), operation: cfg.Create,
+		},
+		{
+			name: "failure sku label cluster wide", keys: "metadata.labels[sku]", scope: "cluster",
+			pdt: product("store-c", "apple-phone", "Apple Phone", "A2111"), operation: cfg.Create,
+			want: []string{
+				"product with the same metadata.labels[sku] already exists as store-a/iphone",
+				"product with the same metadata.labels[sku] already exists as store-b/iphone-eleven",
+			},
+		},
+		{
+			name: "success update of the product itself", keys: "metadata.labels[sku]",
+			pdt: product("store-a", "iphone", "iPhone", "A2111"), operation: cfg.Update,
+		},
+		{
+			name: "success update keeping the identity key of an existing duplicate", keys: "metadata.labels[sku]",
+			scope: "cluster", pdt: product("store-c", "apple-phone", "Apple Phone", "A2111"),
+			oldPdt: product("store-c", "apple-phone", "Apple Phone", "A2111"), operation: cfg.Update,
+		},
+		{
+			name: "failure update changing the identity key", keys: "metadata.labels[sku]", scope: "cluster",
+			pdt:    product("store-c", "apple-phone", "Apple Phone", "A2111"),
+			oldPdt: product("store-c", "apple-phone", "Apple Phone", "B3000"), operation: cfg.Update,
+			want: []string{
+				"product with the same metadata.labels[sku] already exists as store-a/iphone",
+				"product with the same metadata.labels[sku] already exists as store-b/iphone-eleven",
+			},
+		},
+		{
+			name: "success missing key field", keys: "metadata.labels[sku]",
+			pdt: product("store-a", "iphone-11", "iPhone 11", ""), operation: cfg.Create,
+		},
+		{
+			name: "success disabled", pdt: product("store-a", "iphone-11", "iPhone 11", "A2111"), operation: cfg.Create,
+		},
+		{
+			name: "success delete not checked", keys: "metadata.labels[sku]",
+			pdt: product("store-a", "iphone-11", "iPhone 11", "A2111"), operation: cfg.Delete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("app.duplicates", map[string]interface{}{"keys": tt.keys, "scope": tt.scope})
+
+			s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(existing, nil)}
+
+			got := s.findDuplicates(*tt.pdt, tt.oldPdt, tt.operation)
+			assert.ElementsMatch(t, tt.want, violationMessages(got))
+
+			for _, v := range got {
+				assert.Equal(t, "duplicate", v.rule)
+			}
+		})
+	}
+}
+
+func TestServer_validate_duplicate(t *testing.T) {
+	viper.Set("app.duplicates", map[string]interface{}{"keys": "spec.brand, spec.displayName"})
+	defer viper.Set("app.duplicates", nil)
+
+	existing := createProduct("store-a", "iphone", "apple")
+	existing.Spec.DisplayName = "iPhone 11"
+
+	pdt := existing.DeepCopy()
+	pdt.Name = "iphone-11"
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig([]runtime.Object{existing}, nil)}
+
+	_, err := s.validate(*pdt, nil, cfg.Create, authenticationv1.UserInfo{Username: "testuser"})
+	if vErr, ok := err.(*validationError); assert.True(t, ok, "validate() error = %v", err) {
+		assert.Contains(t, vErr.Error(), "already exists as store-a/iphone")
+		assert.Equal(t, "spec.brand", vErr.causes[0].Field)
+	}
+
+	// the opt out does not skip the duplicate check
+	pdt.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+
+	_, err = s.validate(*pdt, nil, cfg.Create, authenticationv1.UserInfo{Username: "testuser"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "already exists as store-a/iphone")
+	}
+}
+
+func TestServer_validate_existingDuplicate(t *testing.T) {
+	viper.Set("app.duplicates", map[string]interface{}{"keys": "spec.brand, spec.displayName"})
+	defer viper.Set("app.duplicates", nil)
+
+	first := createProduct("store-a", "iphone", "apple")
+	first.Spec.DisplayName = "iPhone 11"
+
+	// a duplicate created before the rule, during a warn period or through cache lag
+	oldPdt := first.DeepCopy()
+	oldPdt.Name = "iphone-11"
+	oldPdt.Finalizers = []string{"estore.com/cleanup"}
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig([]runtime.Object{first, oldPdt}, nil)}
+	user := authenticationv1.UserInfo{Username: "testuser"}
+
+	labeled := oldPdt.DeepCopy()
+	labeled.Labels["team"] = "phones"
+
+	_, err := s.validate(*labeled, oldPdt, cfg.Update, user)
+	assert.NoError(t, err, "label change of an existing duplicate")
+
+	finalized := oldPdt.DeepCopy()
+	finalized.Finalizers = nil
+
+	_, err = s.validate(*finalized, oldPdt, cfg.Update, user)
+	assert.NoError(t, err, "finalizer removal of an existing duplicate")
+}
+
+func TestServer_findDuplicates_cache(t *testing.T) {
+	viper.Set("app.duplicates", map[string]interface{}{"keys": "spec.brand, spec.displayName", "scope": "cluster"})
+	defer viper.Set("app.duplicates", nil)
+
+	existing := createProduct("store-a", "iphone", "Apple")
+	existing.Spec.DisplayName = "iPhone 11"
+
+	productCache, err := catalog.New(pdtFake.NewSimpleClientset(existing), 0)
+	if err != nil {
+		t.Fatalf("catalog.New() error = %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	productCache.Start(stopCh)
+	productCache.WaitForSync(stopCh)
+
+	// the api server is not called when the cache is set
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil), Products: productCache}
+
+	pdt := createProduct("store-b", "iphone-11", "apple")
+	pdt.Spec.DisplayName = "iPhone 11"
+
+	assert.Equal(t, []string{"product with the same spec.brand, spec.displayName already exists as store-a/iphone"},
+		violationMessages(s.findDuplicates(*pdt, nil, cfg.Create)))
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+	"github.com/arutselvan15/estore-product-kube-webhook/metrics"
+)
+
+// enforcement modes from the least to the most strict
+var enforcementLevels = map[string]int{
+	cfg.EnforcementAudit:   0,
+	cfg.EnforcementWarn:    1,
+	cfg.EnforcementEnforce: 2,
+}
+
+// violation validation failure of a field and the enforcement mode of the rule reporting it
+type violation struct {
+	rule      string
+	mode      string
+	field     string
+	causeType metav1.CauseType
+	message   string
+}
+
+// newViolations violations of a rule on a field from its messages
+func newViolations(rule, mode, field string, causeType metav1.CauseType, messages []string) []violation {
+	violations := make([]violation, 0, len(messages))
+	for _, m := range messages {
+		violations = append(violations, violation{rule: rule, mode: mode, field: field, causeType: causeType,
+			message: m})
+	}
+
+	return violations
+}
+
+// enforce split the violations into errors and warnings by the enforcement mode in the product namespace,
+// audited violations are only logged
+func enforce(pdt pdtv1.Product, violations []violation) (errors []violation, warnings []string) {
+	namespaceMode := cfg.GetEnforcementMode(pdt.Namespace)
+
+	for _, v := range violations {
+		mode := effectiveMode(v.mode, namespaceMode)
+		metrics.RecordViolation(v.rule, pdt.Namespace, mode)
+
+		switch mode {
+		case cfg.EnforcementAudit:
+			log.LogAuditEvent(fmt.Sprintf("audit mode violation of %s for %s/%s: %s", v.rule, pdt.Namespace,
+				pdt.Name, v.message))
+			log.LogAuditObject(pdt)
+		case cfg.EnforcementWarn:
+			warnings = append(warnings, v.message)
+		default:
+			errors = append(errors, v)
+		}
+	}
+
+	return errors, warnings
+}
+
+// effectiveMode the less strict of the rule and namespace modes, unset or unknown modes enforce. A rule set to
+// enforce is not downgraded by the namespace mode
+func effectiveMode(ruleMode, namespaceMode string) string {
+	mode := cfg.EnforcementEnforce
+
+	if strings.EqualFold(ruleMode, cfg.EnforcementEnforce) {
+		return mode
+	}
+
+	for _, m := range []string{ruleMode, namespaceMode} {
+		level, ok := enforcementLevels[strings.ToLower(m)]
+		if ok && level < enforcementLevels[mode] {
+			mode = strings.ToLower(m)
+		}
+	}
+
+	return mode
+}
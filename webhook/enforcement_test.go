@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func violationMessages(violations []violation) []string {
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.message)
+	}
+
+	return messages
+}
+
+func Test_effectiveMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		ruleMode      string
+		namespaceMode string
+		want          string
+	}{
+		{name: "success default enforce", want: cfg.EnforcementEnforce},
+		{name: "success rule warn", ruleMode: "warn", want: cfg.EnforcementWarn},
+		{name: "success namespace audit", ruleMode: "warn", namespaceMode: "audit", want: cfg.EnforcementAudit},
+		{name: "success less strict wins", ruleMode: "audit", namespaceMode: "enforce", want: cfg.EnforcementAudit},
+		{name: "success rule enforce wins", ruleMode: "Enforce", namespaceMode: "audit", want: cfg.EnforcementEnforce},
+		{name: "success case insensitive", namespaceMode: "WARN", want: cfg.EnforcementWarn},
+		{name: "success unknown enforced", ruleMode: "ignore", want: cfg.EnforcementEnforce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveMode(tt.ruleMode, tt.namespaceMode); got != tt.want {
+				t.Errorf("effectiveMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_enforce(t *testing.T) {
+	viper.Set("app.enforcement.namespaces", map[string]string{"staging": "warn", "sandbox": "audit"})
+	defer viper.Set("app.enforcement.namespaces", nil)
+
+	violations := []violation{
+		{rule: "brand-pattern", message: "brand is invalid"},
+		{rule: "display-name", mode: cfg.EnforcementWarn, message: "display name is required"},
+		{rule: "immutable", mode: cfg.EnforcementEnforce, message: "spec.brand is immutable"},
+	}
+
+	tests := []struct {
+		name         string
+		namespace    string
+		wantErrors   []string
+		wantWarnings []string
+	}{
+		{name: "success enforce namespace", namespace: "sample-ns", wantErrors: []string{"brand is invalid", "spec.brand is immutable"}, wantWarnings: []string{"display name is required"}},
+		{name: "success warn namespace", namespace: "staging", wantErrors: []string{"spec.brand is immutable"}, wantWarnings: []string{"brand is invalid", "display name is required"}},
+		{name: "success audit namespace", namespace: "sandbox", wantErrors: []string{"spec.brand is immutable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdt := createProduct(tt.namespace, "iphone", "apple")
+
+			errors, warnings := enforce(*pdt, violations)
+			if got := violationMessages(errors); !reflect.DeepEqual(got, tt.wantErrors) {
+				t.Errorf("enforce() errors = %v, want %v", errors, tt.wantErrors)
+			}
+
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("enforce() warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestServer_Serve_enforcementWarnings(t *testing.T) {
+	viper.Set("app.enforcement.namespaces", map[string]string{"staging": "warn"})
+	defer viper.Set("app.enforcement.namespaces", nil)
+
+	ar := createAdmissionReview(createProduct("staging", "iphone", "apple2"), "testuser", cfg.Create)
+	ar.APIVersion = "admission.k8s.io/v1"
+	body, _ := json.Marshal(ar)
+
+	recorder := httptest.NewRecorder()
+	request, _ := http.NewRequest("POST", cfg.ValidateURL, bytes.NewReader(body))
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	s.Serve(recorder, request)
+
+	got := admissionReviewV1{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("serve() invalid response = %v", err)
+	}
+
+	assert.True(t, got.Response.Allowed, got.Response.Result)
+	assert.Contains(t, got.Response.Warnings, "spec.brand apple2 is not valid")
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	lc "github.com/arutselvan15/go-utils/logconstants"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+const (
+	freezeLocalTimeLayout = "2006-01-02T15:04:05"
+	freezeClockLayout     = "15:04"
+)
+
+var timeNow = time.Now
+
+// checkFreeze check an active freeze window applies to the operation and return its message, the freeze
+// is enforced by the validating webhook which receives create, update and delete
+func checkFreeze(reqPath, operation string) (bool, string) {
+	if reqPath != cfg.ValidateURL {
+		return false, ""
+	}
+
+	windows, err := cfg.GetFreezeWindows()
+	if err != nil {
+		log.SetStepState(lc.Error).Error(err.Error())
+		return false, ""
+	}
+
+	now := timeNow()
+
+	for _, w := range windows {
+		if !freezeAppliesTo(w.Components, operation) {
+			continue
+		}
+
+		active, err := freezeActive(w, now)
+		if err != nil {
+			// a broken window must not block the catalog
+			log.SetStepState(lc.Error).Errorf("freeze window %s ignored. %s", w.Name, err.Error())
+			continue
+		}
+
+		if active {
+			log.Infof("freeze window %s active for operation %s", w.Name, operation)
+
+			message := w.Message
+			if message == "" {
+				message = fmt.Sprintf("freeze window %s is active", w.Name)
+			}
+
+			return true, message
+		}
+	}
+
+	return false, ""
+}
+
+func freezeAppliesTo(components, operation string) bool {
+	if strings.TrimSpace(components) == "" {
+		return true
+	}
+
+	for _, c := range strings.Split(components, ",") {
+		c = strings.TrimSpace(c)
+		if strings.EqualFold(c, cfg.FreezeComponentAll) || strings.EqualFold(c, cfg.FreezeComponentWebhook) ||
+			strings.EqualFold(c, operation) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func freezeActive(w cfg.FreezeWindow, now time.Time) (bool, error) {
+	loc := time.UTC
+
+	if w.Timezone != "" {
+		l, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return false, fmt.Errorf("unable to load timezone %s, %s", w.Timezone, err.Error())
+		}
+
+		loc = l
+	}
+
+	if w.Recurring() {
+		return recurringFreezeActive(w, now.In(loc), loc)
+	}
+
+	return absoluteFreezeActive(w, now, loc)
+}
+
+func absoluteFreezeActive(w cfg.FreezeWindow, now time.Time, loc *time.Location) (bool, error) {
+	if w.StartTime == "" {
+		return false, nil
+	}
+
+	st, err := parseFreezeTime(w.StartTime, loc)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse freeze start time %s, %s", w.StartTime, err.Error())
+	}
+
+	if now.Before(st) {
+		return false, nil
+	}
+
+	// no end time mentioned considering as no end freeze window
+	if w.EndTime == "" {
+		return true, nil
+	}
+
+	et, err := parseFreezeTime(w.EndTime, loc)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse freeze end time %s, %s", w.EndTime, err.Error())
+	}
+
+	return now.Before(et), nil
+}
+
+func recurringFreezeActive(w cfg.FreezeWindow, now time.Time, loc *time.Location) (bool, error) {
+	start, err := parseFreezeClock(w.Start)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse freeze start %s, %s", w.Start, err.Error())
+	}
+
+	end, err := parseFreezeClock(w.End)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse freeze end %s, %s", w.End, err.Error())
+	}
+
+	days := map[time.Weekday]bool{}
+
+	for _, d := range w.Days {
+		wd, err := parseWeekday(d)
+		if err != nil {
+			return false, err
+		}
+
+		days[wd] = true
+	}
+
+	// a window started yesterday may still be running after midnight
+	for _, offset := range []int{0, -1} {
+		day := now.AddDate(0, 0, offset)
+		if len(days) > 0 && !days[day.Weekday()] {
+			continue
+		}
+
+		ws := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		we := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+
+		if !we.After(ws) {
+			we = we.AddDate(0, 0, 1)
+		}
+
+		if !now.Before(ws) && now.Before(we) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parseFreezeClock clock time of a recurring window, empty is midnight so that a window without start and end
+// lasts the whole day
+func parseFreezeClock(value string) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		value = "00:00"
+	}
+
+	return time.Parse(freezeClockLayout, value)
+}
+
+func parseFreezeTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation(freezeLocalTimeLayout, value, loc)
+}
+
+func parseWeekday(day string) (time.Weekday, error) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := wd.String()
+		if strings.EqualFold(day, name) || strings.EqualFold(day, name[:3]) {
+			return wd, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("unable to parse freeze day %s", day)
+}
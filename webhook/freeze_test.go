@@ -0,0 +1,219 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/admission/v1beta1"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func Test_freezeActive(t *testing.T) {
+	// friday 2020-02-21 19:30 in los angeles
+	la, _ := time.LoadLocation("America/Los_Angeles")
+	fridayEvening := time.Date(2020, 2, 21, 19, 30, 0, 0, la)
+
+	tests := []struct {
+		name    string
+		window  cfg.FreezeWindow
+		now     time.Time
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "success absolute window active",
+			window: cfg.FreezeWindow{StartTime: "2020-02-21T00:00:00-08:00", EndTime: "2020-02-22T00:00:00-08:00"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success absolute window over",
+			window: cfg.FreezeWindow{StartTime: "2020-01-01T21:22:18-08:00", EndTime: "2020-01-02T21:22:18-08:00"},
+			now:    fridayEvening, want: false,
+		},
+		{
+			name:   "success absolute window no end",
+			window: cfg.FreezeWindow{StartTime: "2020-01-01T21:22:18-08:00"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success absolute window in timezone",
+			window: cfg.FreezeWindow{StartTime: "2020-02-21T19:00:00", EndTime: "2020-02-21T20:00:00", Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success absolute window in utc not active",
+			window: cfg.FreezeWindow{StartTime: "2020-02-21T19:00:00", EndTime: "2020-02-21T20:00:00"},
+			now:    fridayEvening, want: false,
+		},
+		{
+			name:   "success recurring window active",
+			window: cfg.FreezeWindow{Days: []string{"friday"}, Start: "18:00", End: "23:59", Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success recurring window other day",
+			window: cfg.FreezeWindow{Days: []string{"mon", "tue"}, Start: "18:00", End: "23:59", Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: false,
+		},
+		{
+			name:   "success recurring window crossing midnight",
+			window: cfg.FreezeWindow{Days: []string{"thursday"}, Start: "22:00", End: "20:00", Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success recurring window evaluated in its timezone",
+			window: cfg.FreezeWindow{Days: []string{"friday"}, Start: "18:00", End: "23:59", Timezone: "Asia/Kolkata"},
+			now:    fridayEvening, want: false,
+		},
+		{
+			name:   "success recurring window whole day",
+			window: cfg.FreezeWindow{Days: []string{"saturday", "friday"}, Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success recurring window whole day other day",
+			window: cfg.FreezeWindow{Days: []string{"saturday", "sunday"}, Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: false,
+		},
+		{
+			name:   "success recurring window until midnight",
+			window: cfg.FreezeWindow{Days: []string{"friday"}, Start: "19:00", Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: true,
+		},
+		{
+			name:   "success recurring window from midnight",
+			window: cfg.FreezeWindow{Days: []string{"friday"}, End: "19:00", Timezone: "America/Los_Angeles"},
+			now:    fridayEvening, want: false,
+		},
+		{
+			name:    "failure invalid timezone",
+			window:  cfg.FreezeWindow{Days: []string{"friday"}, Start: "18:00", End: "23:59", Timezone: "Mars/Olympus"},
+			now:     fridayEvening,
+			wantErr: true,
+		},
+		{
+			name:    "failure invalid day",
+			window:  cfg.FreezeWindow{Days: []string{"someday"}, Start: "18:00", End: "23:59"},
+			now:     fridayEvening,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := freezeActive(tt.window, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("freezeActive() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("freezeActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_freezeAppliesTo(t *testing.T) {
+	tests := []struct {
+		name       string
+		components string
+		operation  string
+		want       bool
+	}{
+		{name: "success all", components: "all", operation: cfg.Create, want: true},
+		{name: "success webhook", components: "controller, webhook", operation: cfg.Delete, want: true},
+		{name: "success operation", components: "create, update", operation: cfg.Update, want: true},
+		{name: "success operation not frozen", components: "create, update", operation: cfg.Delete, want: false},
+		{name: "success other component", components: "controller", operation: cfg.Create, want: false},
+		{name: "success empty components", components: "", operation: cfg.Create, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := freezeAppliesTo(tt.components, tt.operation); got != tt.want {
+				t.Errorf("freezeAppliesTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Serve_freeze(t *testing.T) {
+	viper.Set("app.freeze.windows", []map[string]interface{}{
+		{"name": "release", "days": []string{"friday"}, "start": "18:00", "end": "23:59",
+			"timezone": "America/Los_Angeles", "message": "release freeze", "components": "create"},
+	})
+	defer viper.Set("app.freeze.windows", nil)
+
+	la, _ := time.LoadLocation("America/Los_Angeles")
+	timeNow = func() time.Time { return time.Date(2020, 2, 21, 19, 30, 0, 0, la) }
+
+	defer func() { timeNow = time.Now }()
+
+	pdt := createProduct("sample-ns", "sample-prd", "apple")
+
+	tests := []struct {
+		name      string
+		reqPath   string
+		user      string
+		groups    []string
+		namespace string
+		operation v1beta1.Operation
+		allowed   bool
+		message   string
+	}{
+		{name: "failure create frozen", reqPath: cfg.ValidateURL, user: "testuser", namespace: "sample-ns", operation: cfg.Create, allowed: false, message: "release freeze"},
+		{name: "success update not frozen", reqPath: cfg.ValidateURL, user: "testuser", namespace: "sample-ns", operation: cfg.Update, allowed: true},
+		{name: "success mutate not frozen", reqPath: cfg.MutateURL, user: "testuser", namespace: "sample-ns", operation: cfg.Create, allowed: true},
+		{name: "success system user exempt", reqPath: cfg.ValidateURL, user: "system:serviceaccount:kube-system:admin", namespace: "sample-ns", operation: cfg.Create, allowed: true},
+		{name: "success system namespace exempt", reqPath: cfg.ValidateURL, user: "testuser", namespace: "kube-system", operation: cfg.Create, allowed: true},
+		{name: "success privileged group exempt", reqPath: cfg.ValidateURL, user: "testuser", groups: []string{"estore:release-managers"}, namespace: "sample-ns", operation: cfg.Create, allowed: true},
+		{name: "failure privileged group prefix not exempt", reqPath: cfg.ValidateURL, user: "testuser", groups: []string{"estore:release-managers-interns"}, namespace: "sample-ns", operation: cfg.Create, allowed: false, message: "release freeze"},
+		{name: "failure other group frozen", reqPath: cfg.ValidateURL, user: "testuser", groups: []string{"estore:developers"}, namespace: "sample-ns", operation: cfg.Create, allowed: false, message: "release freeze"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("app.system.users", "system:serviceaccount:kube")
+			viper.Set("app.system.namespaces", "kube")
+
+			viper.Set("app.privileged.groups", "estore:release-managers")
+
+			defer viper.Set("app.system.users", nil)
+			defer viper.Set("app.system.namespaces", nil)
+			defer viper.Set("app.privileged.groups", nil)
+
+			p := pdt.DeepCopy()
+			p.Namespace = tt.namespace
+			ar := createAdmissionReview(p, tt.user, tt.operation)
+			ar.Request.UserInfo.Groups = tt.groups
+
+			recorder := httptest.NewRecorder()
+			body, _ := json.Marshal(ar)
+			request, _ := http.NewRequest("POST", tt.reqPath, bytes.NewReader(body))
+
+			s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+			s.Serve(recorder, request)
+
+			res, err := decodeAdmissionReview(recorder.Body)
+			if err != nil {
+				t.Fatalf("serve() error = %v", err)
+			}
+
+			assert.Equal(t, tt.allowed, res.Response.Allowed, res.Response.Result)
+
+			if tt.message != "" {
+				assert.Equal(t, tt.message, res.Response.Result.Message)
+			}
+		})
+	}
+}
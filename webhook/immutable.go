@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// validateImmutableFields reject changes of the configured immutable fields on update
+func validateImmutableFields(pdt, oldPdt pdtv1.Product, userInfo authenticationv1.UserInfo) []violation {
+	var violations []violation
+
+	fields := cfg.GetImmutableFields()
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if immutableOverrideAllowed(pdt, userInfo) {
+		log.Infof("immutable fields check overridden by user %s with annotation %s", userInfo.Username,
+			cfg.GetImmutableOverrideAnnotation())
+		return nil
+	}
+
+	newObj, err := toUnstructured(pdt)
+	if err != nil {
+		return newViolations("immutable", cfg.EnforcementEnforce, "", metav1.CauseTypeUnexpectedServerResponse, []string{err.Error()})
+	}
+
+	oldObj, err := toUnstructured(oldPdt)
+	if err != nil {
+		return newViolations("immutable", cfg.EnforcementEnforce, "", metav1.CauseTypeUnexpectedServerResponse, []string{err.Error()})
+	}
+
+	for _, field := range fields {
+		oldValue, _ := lookupField(oldObj, field)
+		newValue, _ := lookupField(newObj, field)
+
+		if !reflect.DeepEqual(oldValue, newValue) {
+			violations = append(violations, violation{rule: "immutable", mode: cfg.EnforcementEnforce, field: field,
+				causeType: metav1.CauseTypeFieldValueInvalid, message: fmt.Sprintf(
+					"%s is immutable, old value %s, new value %s", field, formatFieldValue(oldValue),
+					formatFieldValue(newValue))})
+		}
+	}
+
+	return violations
+}
+
+// immutableOverrideAllowed override annotation is set by a user of the override groups
+func immutableOverrideAllowed(pdt pdtv1.Product, userInfo authenticationv1.UserInfo) bool {
+	if !strings.EqualFold(pdt.GetAnnotations()[cfg.GetImmutableOverrideAnnotation()], "true") {
+		return false
+	}
+
+	return userInGroups(userInfo, cfg.GetImmutableOverrideGroups())
+}
+
+func formatFieldValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+
+	return fmt.Sprintf("%v", value)
+}
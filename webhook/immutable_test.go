@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func Test_validateImmutableFields(t *testing.T) {
+	viper.Set("app.immutable.fields", "spec.brand, metadata.labels[product.estore.com/brand]")
+	viper.Set("app.immutable.overrideGroups", "estore:admins")
+
+	defer viper.Set("app.immutable.fields", nil)
+	defer viper.Set("app.immutable.overrideGroups", nil)
+
+	oldPdt := createProduct("sample-ns", "sample-prd", "apple")
+	oldPdt.Labels[pdtv1.ProductLabelBrand] = "apple"
+
+	changedBrand := oldPdt.DeepCopy()
+	changedBrand.Spec.Brand = "samsung"
+
+	removedLabel := oldPdt.DeepCopy()
+	delete(removedLabel.Labels, pdtv1.ProductLabelBrand)
+
+	changedDescription := oldPdt.DeepCopy()
+	changedDescription.Spec.Description = "new description"
+
+	overridden := changedBrand.DeepCopy()
+	overridden.Annotations[cfg.ImmutableOverrideAnnotation] = "true"
+
+	user := authenticationv1.UserInfo{Username: "testuser", Groups: []string{"system:authenticated"}}
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:authenticated", "estore:admins"}}
+
+	tests := []struct {
+		name     string
+		pdt      *pdtv1.Product
+		userInfo authenticationv1.UserInfo
+		want     []string
+	}{
+		{name: "success no change", pdt: oldPdt, userInfo: user},
+		{name: "success mutable field changed", pdt: changedDescription, userInfo: user},
+		{
+			name: "failure brand changed", pdt: changedBrand, userInfo: user,
+			want: []string{"spec.brand is immutable, old value apple, new value samsung"},
+		},
+		{
+			name: "failure label removed", pdt: removedLabel, userInfo: user,
+			want: []string{"metadata.labels[product.estore.com/brand] is immutable, old value apple, new value <none>"},
+		},
+		{
+			name: "failure override annotation without group", pdt: overridden, userInfo: user,
+			want: []string{"spec.brand is immutable, old value apple, new value samsung"},
+		},
+		{name: "success override annotation with group", pdt: overridden, userInfo: admin},
+		{
+			name: "failure privileged group without annotation", pdt: changedBrand, userInfo: admin,
+			want: []string{"spec.brand is immutable, old value apple, new value samsung"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationMessages(validateImmutableFields(*tt.pdt, *oldPdt, tt.userInfo))
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("validateImmutableFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateProduct_immutable(t *testing.T) {
+	viper.Set("app.immutable.fields", "spec.brand")
+	defer viper.Set("app.immutable.fields", nil)
+
+	oldPdt := createProduct("sample-ns", "sample-prd", "apple")
+	pdt := oldPdt.DeepCopy()
+	pdt.Spec.Brand = "samsung"
+
+	user := authenticationv1.UserInfo{Username: "testuser"}
+
+	if _, err := validateProduct(*pdt, oldPdt, cfg.Update, user, nil); err == nil {
+		t.Errorf("validateProduct() update error = nil, want immutable error")
+	}
+
+	if _, err := validateProduct(*pdt, nil, cfg.Create, user, nil); err != nil {
+		t.Errorf("validateProduct() create error = %v, want nil", err)
+	}
+}
@@ -1,33 +1,23 @@
 package webhook
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
 
-	cv "github.com/arutselvan15/estore-common/validate"
 	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
 
 	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
 )
 
-// MutateProduct mutate product
-func MutateProduct(pdt pdtv1.Product, operation, user string) ([]byte, error) {
-	var (
-		addAnnotations       = map[string]string{}
-		addLabels            = map[string]string{}
-		availableAnnotations = pdt.GetAnnotations()
-		availableLabels      = pdt.GetLabels()
-	)
-
-	if availableAnnotations == nil {
-		availableAnnotations = map[string]string{}
-	}
-
-	if availableLabels == nil {
-		availableLabels = map[string]string{}
-	}
+// mutationRevision revision of the mutation defaults, bump it when the defaults change
+const mutationRevision = 1
 
+// MutateProduct mutate product, the desired product is recomputed on every create and update and only the
+// operations needed to reach it are returned, oldPdt is the stored product of an update
+func MutateProduct(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation, user string) ([]byte, error) {
 	if user == "" {
 		return nil, fmt.Errorf("user not found in request")
 	}
@@ -36,14 +26,86 @@ func MutateProduct(pdt pdtv1.Product, operation, user string) ([]byte, error) {
 		return nil, nil
 	}
 
-	if strings.EqualFold(availableAnnotations[pdtv1.ProductAnnotationWebhookStatusKey], cfg.Mutated) {
-		return nil, nil
+	desired, err := desiredProduct(pdt, oldPdt, operation, user)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := createPatch(pdt, desired)
+	if err != nil {
+		return nil, err
 	}
 
-	// add annotation to mark the resource as mutated
-	addAnnotations[pdtv1.ProductAnnotationWebhookStatusKey] = cfg.Mutated
-	patch := cv.CreatePatchAnnotations(availableAnnotations, addAnnotations)
-	patch = append(patch, cv.CreatePatchLabels(availableLabels, addLabels)...)
+	if len(patch) == 0 {
+		return nil, nil
+	}
 
 	return json.Marshal(patch)
 }
+
+// desiredProduct product with the mutation defaults applied
+func desiredProduct(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation, user string) (pdtv1.Product, error) {
+	desired := *pdt.DeepCopy()
+
+	if desired.Annotations == nil {
+		desired.Annotations = map[string]string{}
+	}
+
+	// categories are normalized on every change so that edits stay consistent with the catalog
+	desired.Spec.Categories = normalizeCategories(desired.Spec.Categories)
+
+	// a full replace of an update may leave out the created stamps, they are kept from the stored product
+	if strings.EqualFold(operation, cfg.Update) && oldPdt != nil {
+		oldAnnotations := oldPdt.GetAnnotations()
+
+		for _, k := range []string{cfg.CreatedByAnnotation, cfg.CreatedAtAnnotation} {
+			if v, ok := oldAnnotations[k]; ok {
+				desired.Annotations[k] = v
+			}
+		}
+	}
+
+	// a reinvocation of the same create keeps the stamps of the first pass
+	if !createStamped(desired, operation, user) {
+		for k, v := range stampAnnotations(operation, user) {
+			desired.Annotations[k] = v
+		}
+	}
+
+	status, err := mutationStatus(desired)
+	if err != nil {
+		return desired, err
+	}
+
+	desired.Annotations[pdtv1.ProductAnnotationWebhookStatusKey] = status
+
+	return desired, nil
+}
+
+// mutationStatus status annotation value with the mutation revision and the hash of the spec, the labels and the
+// annotations, stamps and the status itself are left out so that the hash does not change with every request
+func mutationStatus(pdt pdtv1.Product) (string, error) {
+	annotations := map[string]string{}
+
+	for k, v := range pdt.Annotations {
+		switch k {
+		case pdtv1.ProductAnnotationWebhookStatusKey, cfg.CreatedByAnnotation, cfg.CreatedAtAnnotation,
+			cfg.LastUpdatedByAnnotation, cfg.LastUpdatedAtAnnotation:
+		default:
+			annotations[k] = v
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Annotations map[string]string `json:"annotations,omitempty"`
+		Labels      map[string]string `json:"labels,omitempty"`
+		Spec        pdtv1.ProductSpec `json:"spec"`
+	}{Annotations: annotations, Labels: pdt.Labels, Spec: pdt.Spec})
+	if err != nil {
+		return "", fmt.Errorf("can't hash product: %s", err.Error())
+	}
+
+	sum := sha256.Sum256(data)
+
+	return fmt.Sprintf("%s-r%d-%s", cfg.Mutated, mutationRevision, hex.EncodeToString(sum[:])[:12]), nil
+}
@@ -1,7 +1,12 @@
 package webhook
 
 import (
+	"encoding/json"
 	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
 
 	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
 
@@ -18,6 +23,8 @@ func TestMutateProduct(t *testing.T) {
 	pdt := createProduct("sample-ns", "sample-prd", "apple")
 	alreadyMutatedPdt := pdt.DeepCopy()
 	alreadyMutatedPdt.Annotations[pdtv1.ProductAnnotationWebhookStatusKey] = cfg.Mutated
+	unsortedCategoriesPdt := alreadyMutatedPdt.DeepCopy()
+	unsortedCategoriesPdt.Spec.Categories = []string{"Electronics", "cellphones"}
 
 	tests := []struct {
 		name    string
@@ -35,13 +42,16 @@ func TestMutateProduct(t *testing.T) {
 			name: "success no mutate pdt on delete", args: args{operation: cfg.Delete, pdt: *pdt, user: "system"}, want: false,
 		},
 		{
-			name: "success no mutate pdt already mutation done", args: args{operation: cfg.Update, pdt: *alreadyMutatedPdt, user: "system"}, want: false,
+			name: "success stamp update already mutation done", args: args{operation: cfg.Update, pdt: *alreadyMutatedPdt, user: "system"}, want: true,
+		},
+		{
+			name: "success normalize categories already mutation done", args: args{operation: cfg.Update, pdt: *unsortedCategoriesPdt, user: "system"}, want: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := MutateProduct(tt.args.pdt, tt.args.operation, tt.args.user)
+			got, err := MutateProduct(tt.args.pdt, nil, tt.args.operation, tt.args.user)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("MutateProduct() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -52,3 +62,73 @@ func TestMutateProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestMutateProduct_idempotent(t *testing.T) {
+	// the clock moves between the passes of the mutating webhook
+	now := time.Date(2020, 2, 21, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time {
+		now = now.Add(5 * time.Second)
+		return now
+	}
+
+	defer func() { timeNow = time.Now }()
+
+	pdt := createProduct("sample-ns", "sample-prd", "apple")
+	pdt.Annotations = nil
+	pdt.Spec.Categories = []string{"Home/Kitchen", "cellphones"}
+
+	mutated := applyMutation(t, *pdt, cfg.Create)
+	assert.Equal(t, []string{"cellphones", "home/kitchen"}, mutated.Spec.Categories)
+
+	assert.Equal(t, "2020-02-21T12:00:05Z", mutated.Annotations[cfg.CreatedAtAnnotation])
+
+	// mutating the mutated product again is a no-op
+	patch, err := MutateProduct(mutated, nil, cfg.Create, "testuser")
+	assert.NoError(t, err)
+	assert.Nil(t, patch, string(patch))
+
+	// stamps of another user or of an earlier time are not kept
+	forged := *mutated.DeepCopy()
+	forged.Annotations[cfg.CreatedAtAnnotation] = "2019-01-01T00:00:00Z"
+
+	restamped := applyMutation(t, forged, cfg.Create)
+	assert.True(t, admissionTime(restamped.Annotations[cfg.CreatedAtAnnotation]))
+	assert.Equal(t, mutated.Annotations[pdtv1.ProductAnnotationWebhookStatusKey],
+		restamped.Annotations[pdtv1.ProductAnnotationWebhookStatusKey])
+
+	// a user edit that undoes a default is repaired on update
+	edited := *mutated.DeepCopy()
+	edited.Spec.Categories = []string{"Cellphones"}
+
+	repaired := applyMutation(t, edited, cfg.Update)
+	assert.Equal(t, []string{"cellphones"}, repaired.Spec.Categories)
+	assert.NotEqual(t, mutated.Annotations[pdtv1.ProductAnnotationWebhookStatusKey],
+		repaired.Annotations[pdtv1.ProductAnnotationWebhookStatusKey], "hash follows the mutated spec")
+	assert.Equal(t, "testuser", repaired.Annotations[cfg.LastUpdatedByAnnotation])
+}
+
+func applyMutation(t *testing.T, pdt pdtv1.Product, operation string) pdtv1.Product {
+	data, err := MutateProduct(pdt, nil, operation, "testuser")
+	if err != nil {
+		t.Fatalf("MutateProduct() error = %v", err)
+	}
+
+	patch, err := jsonpatch.DecodePatch(data)
+	if err != nil {
+		t.Fatalf("MutateProduct() invalid patch = %v", err)
+	}
+
+	original, _ := json.Marshal(pdt)
+
+	patched, err := patch.Apply(original)
+	if err != nil {
+		t.Fatalf("MutateProduct() patch does not apply = %v", err)
+	}
+
+	result := pdtv1.Product{}
+	if err := json.Unmarshal(patched, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
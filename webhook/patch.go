@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	cv "github.com/arutselvan15/estore-common/validate"
+)
+
+// createPatch json patch operations turning the original object into the desired one, maps are patched per
+// key and any other changed value is replaced as a whole
+func createPatch(original, desired interface{}) ([]cv.PatchOperation, error) {
+	originalObj, err := toJSONValue(original)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredObj, err := toJSONValue(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffValues("", originalObj, desiredObj), nil
+}
+
+func diffValues(path string, original, desired interface{}) []cv.PatchOperation {
+	originalMap, originalIsMap := original.(map[string]interface{})
+	desiredMap, desiredIsMap := desired.(map[string]interface{})
+
+	if !originalIsMap || !desiredIsMap {
+		if reflect.DeepEqual(original, desired) {
+			return nil
+		}
+
+		return []cv.PatchOperation{{Op: "replace", Path: path, Value: desired}}
+	}
+
+	var patch []cv.PatchOperation
+
+	for _, key := range sortedKeys(originalMap, desiredMap) {
+		keyPath := path + "/" + escapePointer(key)
+		originalValue, inOriginal := originalMap[key]
+		desiredValue, inDesired := desiredMap[key]
+
+		switch {
+		case !inDesired:
+			patch = append(patch, cv.PatchOperation{Op: "remove", Path: keyPath})
+		case !inOriginal:
+			patch = append(patch, cv.PatchOperation{Op: "add", Path: keyPath, Value: desiredValue})
+		default:
+			patch = append(patch, diffValues(keyPath, originalValue, desiredValue)...)
+		}
+	}
+
+	return patch
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+
+	var keys []string
+
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// escapePointer escape a json pointer reference token
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func toJSONValue(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal object: %s", err.Error())
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("can't unmarshal object: %s", err.Error())
+	}
+
+	return value, nil
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	cv "github.com/arutselvan15/estore-common/validate"
+)
+
+func Test_createPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		original map[string]interface{}
+		desired  map[string]interface{}
+		want     []cv.PatchOperation
+	}{
+		{
+			name:     "success no change",
+			original: map[string]interface{}{"spec": map[string]interface{}{"brand": "apple"}},
+			desired:  map[string]interface{}{"spec": map[string]interface{}{"brand": "apple"}},
+		},
+		{
+			name:     "success add escaped key",
+			original: map[string]interface{}{"annotations": map[string]interface{}{}},
+			desired:  map[string]interface{}{"annotations": map[string]interface{}{"a/b~c": "x"}},
+			want:     []cv.PatchOperation{{Op: "add", Path: "/annotations/a~1b~0c", Value: "x"}},
+		},
+		{
+			name:     "success add missing map",
+			original: map[string]interface{}{},
+			desired:  map[string]interface{}{"labels": map[string]interface{}{"a": "b"}},
+			want:     []cv.PatchOperation{{Op: "add", Path: "/labels", Value: map[string]interface{}{"a": "b"}}},
+		},
+		{
+			name:     "success replace list and remove key in order",
+			original: map[string]interface{}{"b": []interface{}{"x", "y"}, "a": "gone"},
+			desired:  map[string]interface{}{"b": []interface{}{"y", "x"}},
+			want: []cv.PatchOperation{
+				{Op: "remove", Path: "/a"},
+				{Op: "replace", Path: "/b", Value: []interface{}{"y", "x"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := createPatch(tt.original, tt.desired)
+			if err != nil {
+				t.Fatalf("createPatch() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("createPatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// validatePrice check the price bounds on create, the price bounds and change of an update changing the price and
+// the category bounds of the categories added by an update
+func validatePrice(pdt pdtv1.Product, oldPdt *pdtv1.Product) []string {
+	var errors []string
+
+	if !cfg.PricePolicyEnabled() {
+		return nil
+	}
+
+	policy, err := cfg.GetPricePolicy()
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	price := pdt.Spec.Price
+	categories := priceCategories(pdt.Spec.Categories)
+
+	// an update keeping the price is only checked against the categories it adds, products without a price can
+	// still get their metadata changed and their finalizers removed
+	if oldPdt != nil && price == oldPdt.Spec.Price {
+		oldCategories := map[string]bool{}
+		for _, c := range priceCategories(oldPdt.Spec.Categories) {
+			oldCategories[c] = true
+		}
+
+		var added []string
+
+		for _, c := range categories {
+			if !oldCategories[c] {
+				added = append(added, c)
+			}
+		}
+
+		return checkCategoryPriceBounds(price, added, policy)
+	}
+
+	if price <= 0 {
+		return []string{fmt.Sprintf("spec.price %v must be greater than 0", price)}
+	}
+
+	if err := checkPriceBounds(price, policy.PriceBounds, "spec.price"); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	errors = append(errors, checkCategoryPriceBounds(price, categories, policy)...)
+
+	if oldPdt != nil {
+		if err := checkPriceChange(pdt, oldPdt.Spec.Price, policy); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	return errors
+}
+
+// priceCategories sorted lower case categories, category keys are lower case in the configuration
+func priceCategories(categories []string) []string {
+	lower := make([]string, 0, len(categories))
+	for _, c := range categories {
+		lower = append(lower, strings.ToLower(c))
+	}
+
+	sort.Strings(lower)
+
+	return lower
+}
+
+func checkCategoryPriceBounds(price float64, categories []string, policy cfg.PricePolicy) []string {
+	var errors []string
+
+	for _, c := range categories {
+		if bounds, ok := policy.Categories[c]; ok {
+			if err := checkPriceBounds(price, bounds, fmt.Sprintf("spec.price for category %s", c)); err != nil {
+				errors = append(errors, err.Error())
+			}
+		}
+	}
+
+	return errors
+}
+
+func checkPriceBounds(price float64, bounds cfg.PriceBounds, field string) error {
+	if bounds.Min != nil && price < *bounds.Min {
+		return fmt.Errorf("%s %v is less than minimum %v", field, price, *bounds.Min)
+	}
+
+	if bounds.Max != nil && price > *bounds.Max {
+		return fmt.Errorf("%s %v is greater than maximum %v", field, price, *bounds.Max)
+	}
+
+	return nil
+}
+
+func checkPriceChange(pdt pdtv1.Product, oldPrice float64, policy cfg.PricePolicy) error {
+	price := pdt.Spec.Price
+
+	if policy.MaxChangePercent <= 0 || oldPrice <= 0 || price == oldPrice {
+		return nil
+	}
+
+	if strings.EqualFold(pdt.GetAnnotations()[policy.ApprovalAnnotation], "true") {
+		log.Infof("spec.price change from %v to %v approved by annotation %s", oldPrice, price,
+			policy.ApprovalAnnotation)
+		return nil
+	}
+
+	change := math.Abs(price-oldPrice) / oldPrice * 100
+	if change <= policy.MaxChangePercent {
+		return nil
+	}
+
+	delta := oldPrice * policy.MaxChangePercent / 100
+
+	return fmt.Errorf("spec.price change from %v to %v is %.2f%% which exceeds the allowed %v%% "+
+		"(allowed %v to %v), set annotation %s to \"true\" to approve", oldPrice, price, change,
+		policy.MaxChangePercent, oldPrice-delta, oldPrice+delta, policy.ApprovalAnnotation)
+}
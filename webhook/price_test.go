@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func Test_validatePrice(t *testing.T) {
+	viper.Set("app.price", map[string]interface{}{
+		"min": 1, "max": 10000, "maxChangePercent": 50,
+		"categories": map[string]interface{}{"cellphones": map[string]interface{}{"min": 50, "max": 5000}},
+	})
+	defer viper.Set("app.price", nil)
+
+	pdtWithPrice := func(price float64, categories ...string) *pdtv1.Product {
+		pdt := createProduct("sample-ns", "sample-prd", "apple")
+		pdt.Spec.Price = price
+		pdt.Spec.Categories = categories
+
+		return pdt
+	}
+
+	approved := pdtWithPrice(300)
+	approved.Annotations[cfg.PriceApprovalAnnotation] = "true"
+
+	tests := []struct {
+		name   string
+		pdt    *pdtv1.Product
+		oldPdt *pdtv1.Product
+		want   []string
+	}{
+		{name: "success price in bounds", pdt: pdtWithPrice(100, "Cellphones")},
+		{name: "failure zero price", pdt: pdtWithPrice(0), want: []string{"spec.price 0 must be greater than 0"}},
+		{name: "failure negative price", pdt: pdtWithPrice(-5), want: []string{"spec.price -5 must be greater than 0"}},
+		{name: "failure above maximum", pdt: pdtWithPrice(20000), want: []string{"spec.price 20000 is greater than maximum 10000"}},
+		{
+			name: "failure category minimum", pdt: pdtWithPrice(10, "cellphones"),
+			want: []string{"spec.price for category cellphones 10 is less than minimum 50"},
+		},
+		{name: "success change in allowed percentage", pdt: pdtWithPrice(140), oldPdt: pdtWithPrice(100)},
+		{
+			name: "failure change above allowed percentage", pdt: pdtWithPrice(300), oldPdt: pdtWithPrice(100),
+			want: []string{"spec.price change from 100 to 300 is 200.00% which exceeds the allowed 50% (allowed 50 to 150), " +
+				"set annotation product.estore.com/price-change-approved to \"true\" to approve"},
+		},
+		{name: "success change approved", pdt: approved, oldPdt: pdtWithPrice(100)},
+		{name: "success update without price change", pdt: pdtWithPrice(0), oldPdt: pdtWithPrice(0)},
+		{name: "success update keeps price out of bounds", pdt: pdtWithPrice(20000), oldPdt: pdtWithPrice(20000)},
+		{
+			name: "failure update adds category with stricter bounds", pdt: pdtWithPrice(20, "accessories", "Cellphones"),
+			oldPdt: pdtWithPrice(20, "accessories"),
+			want:   []string{"spec.price for category cellphones 20 is less than minimum 50"},
+		},
+		{
+			name: "success update keeps category out of bounds", pdt: pdtWithPrice(20, "cellphones", "accessories"),
+			oldPdt: pdtWithPrice(20, "cellphones"),
+		},
+		{
+			name: "failure update removes price", pdt: pdtWithPrice(0), oldPdt: pdtWithPrice(100),
+			want: []string{"spec.price 0 must be greater than 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validatePrice(*tt.pdt, tt.oldPdt)
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("validatePrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lc "github.com/arutselvan15/go-utils/logconstants"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// checkQuota quota violations of a product created in the namespace, the existing products are counted with
+// the product lister, a failed count is logged and not reported so that the catalog is not blocked
+func (s Server) checkQuota(pdt pdtv1.Product, operation string) []violation {
+	lister := s.products()
+
+	if lister == nil || !strings.EqualFold(operation, cfg.Create) {
+		return nil
+	}
+
+	quota, err := cfg.GetQuota(pdt.Namespace)
+	if err != nil {
+		log.SetStepState(lc.Error).Errorf("quota check skipped. %s", err.Error())
+		return nil
+	}
+
+	if quota.IsZero() {
+		return nil
+	}
+
+	existing, err := lister.List(pdt.Namespace)
+	if err != nil {
+		log.SetStepState(lc.Error).Errorf("quota check skipped, unable to list products. %s", err.Error())
+		return nil
+	}
+
+	var violations []violation
+
+	newViolation := func(field, scope string, used, limit int) {
+		violations = append(violations, violation{
+			rule:      "quota",
+			mode:      quota.Enforcement,
+			field:     field,
+			causeType: metav1.CauseTypeFieldValueInvalid,
+			message: fmt.Sprintf("namespace %s product quota%s exceeded, %d of %d products used",
+				pdt.Namespace, scope, used, limit),
+		})
+	}
+
+	if quota.Products > 0 && len(existing) >= quota.Products {
+		newViolation("metadata.namespace", "", len(existing), quota.Products)
+	}
+
+	if limit := quota.BrandLimit(pdt.Spec.Brand); limit > 0 && pdt.Spec.Brand != "" {
+		used := 0
+
+		for _, e := range existing {
+			if strings.EqualFold(e.Spec.Brand, pdt.Spec.Brand) {
+				used++
+			}
+		}
+
+		if used >= limit {
+			newViolation("spec.brand", fmt.Sprintf(" for brand %s", strings.ToLower(pdt.Spec.Brand)), used, limit)
+		}
+	}
+
+	limits := categoryLimits(pdt.Spec.Categories, quota.Categories)
+	categories := make([]string, 0, len(limits))
+
+	for c := range limits {
+		categories = append(categories, c)
+	}
+
+	sort.Strings(categories)
+
+	for _, c := range categories {
+		used := 0
+
+		for _, e := range existing {
+			if hasCategoryWithin(e.Spec.Categories, c) {
+				used++
+			}
+		}
+
+		if used >= limits[c] {
+			newViolation("spec.categories", fmt.Sprintf(" for category %s", c), used, limits[c])
+		}
+	}
+
+	return violations
+}
+
+// categoryLimits limits applying to the categories, a limit of a parent category applies to its children and
+// the wildcard limit applies to categories without their own or a parent limit
+func categoryLimits(categories []string, quota map[string]int) map[string]int {
+	limits := map[string]int{}
+
+	for _, c := range categories {
+		c = normalizeCategory(c)
+		limited := false
+
+		for k, limit := range quota {
+			if k == cfg.QuotaWildcard {
+				continue
+			}
+
+			if k = normalizeCategory(k); categoryWithin(c, k) {
+				// a limit of 0 disables the wildcard without setting a limit
+				limited = true
+
+				if limit > 0 {
+					limits[k] = limit
+				}
+			}
+		}
+
+		if limit, ok := quota[cfg.QuotaWildcard]; ok && limit > 0 && !limited {
+			limits[c] = limit
+		}
+	}
+
+	return limits
+}
+
+func hasCategoryWithin(categories []string, parent string) bool {
+	for _, c := range categories {
+		if categoryWithin(normalizeCategory(c), parent) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func TestServer_checkQuota(t *testing.T) {
+	defer viper.Set("app.quotas", nil)
+
+	product := func(namespace, name, brand string, categories ...string) *pdtv1.Product {
+		pdt := createProduct(namespace, name, brand)
+		pdt.Spec.Categories = categories
+
+		return pdt
+	}
+
+	existing := []runtime.Object{
+		product("store-a", "iphone", "apple", "electronics/cellphones"),
+		product("store-a", "macbook", "Apple", "electronics/laptops"),
+		product("store-a", "pixel", "google", "electronics/cellphones"),
+		product("store-b", "toaster", "acme", "home/kitchen"),
+	}
+
+	tests := []struct {
+		name      string
+		quotas    map[string]interface{}
+		pdt       *pdtv1.Product
+		operation string
+		want      []string
+	}{
+		{
+			name:   "success under default quota",
+			quotas: map[string]interface{}{"default": map[string]interface{}{"products": 4}},
+			pdt:    product("store-a", "ipad", "apple"), operation: cfg.Create,
+		},
+		{
+			name:   "failure default quota",
+			quotas: map[string]interface{}{"default": map[string]interface{}{"products": 3}},
+			pdt:    product("store-a", "ipad", "apple"), operation: cfg.Create,
+			want: []string{"namespace store-a product quota exceeded, 3 of 3 products used"},
+		},
+		{
+			name: "success namespace override replaces default",
+			quotas: map[string]interface{}{
+				"default":    map[string]interface{}{"products": 1},
+				"namespaces": map[string]interface{}{"store-a": map[string]interface{}{"products": 10}},
+			},
+			pdt: product("store-a", "ipad", "apple"), operation: cfg.Create,
+		},
+		{
+			name: "failure brand quota",
+			quotas: map[string]interface{}{
+				"namespaces": map[string]interface{}{"store-a": map[string]interface{}{
+					"brands": map[string]interface{}{"apple": 2, "*": 5},
+				}},
+			},
+			pdt: product("store-a", "ipad", "APPLE"), operation: cfg.Create,
+			want: []string{"namespace store-a product quota for brand apple exceeded, 2 of 2 products used"},
+		},
+		{
+			name: "failure wildcard brand quota",
+			quotas: map[string]interface{}{
+				"default": map[string]interface{}{"brands": map[string]interface{}{"*": 1}},
+			},
+			pdt: product("store-a", "pixel-4", "google"), operation: cfg.Create,
+			want: []string{"namespace store-a product quota for brand google exceeded, 1 of 1 products used"},
+		},
+		{
+			name: "failure parent category quota",
+			quotas: map[string]interface{}{
+				"default": map[string]interface{}{"categories": map[string]interface{}{"electronics": 3, "*": 10}},
+			},
+			pdt: product("store-a", "galaxy", "samsung", "Electronics/Cellphones"), operation: cfg.Create,
+			want: []string{"namespace store-a product quota for category electronics exceeded, 3 of 3 products used"},
+		},
+		{
+			name: "failure wildcard category quota",
+			quotas: map[string]interface{}{
+				"default": map[string]interface{}{"categories": map[string]interface{}{"*": 2}},
+			},
+			pdt:       product("store-a", "galaxy", "samsung", "electronics/cellphones", "electronics/laptops"),
+			operation: cfg.Create,
+			want:      []string{"namespace store-a product quota for category electronics/cellphones exceeded, 2 of 2 products used"},
+		},
+		{
+			name: "success parent category quota replaces wildcard",
+			quotas: map[string]interface{}{
+				"default": map[string]interface{}{"categories": map[string]interface{}{"electronics": 10, "*": 1}},
+			},
+			pdt: product("store-a", "galaxy", "samsung", "electronics/cellphones"), operation: cfg.Create,
+		},
+		{
+			name:   "success update not checked",
+			quotas: map[string]interface{}{"default": map[string]interface{}{"products": 1}},
+			pdt:    product("store-a", "iphone", "apple"), operation: cfg.Update,
+		},
+		{
+			name: "success no quota",
+			pdt:  product("store-a", "ipad", "apple"), operation: cfg.Create,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set("app.quotas", tt.quotas)
+
+			s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(existing, nil)}
+
+			got := s.checkQuota(*tt.pdt, tt.operation)
+			assert.Equal(t, tt.want, violationMessages(got))
+		})
+	}
+}
+
+func TestServer_validate_quotaOptOut(t *testing.T) {
+	viper.Set("app.quotas", map[string]interface{}{"default": map[string]interface{}{"products": 1}})
+	defer viper.Set("app.quotas", nil)
+
+	existing := createProduct("store-a", "iphone", "apple")
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig([]runtime.Object{existing}, nil)}
+
+	// the opt out does not skip the quota
+	pdt := createProduct("store-a", "ipad", "apple")
+	pdt.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+
+	_, err := s.validate(*pdt, nil, cfg.Create, authenticationv1.UserInfo{Username: "testuser"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "namespace store-a product quota exceeded, 1 of 1 products used")
+	}
+}
@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"sigs.k8s.io/yaml"
+)
+
+// ReviewResult result of an offline admission review
+type ReviewResult struct {
+	// Response admission review encoded in the version of the request
+	Response []byte
+	Allowed  bool
+	Message  string
+	Warnings []string
+	// Patch json patch of the mutation, nil when nothing is mutated
+	Patch []byte
+	// Patched request object with the patch applied, nil without patch
+	Patched []byte
+}
+
+// Review run a json or yaml admission review through the same checks as Serve for the request path
+func (s Server) Review(reqPath string, data []byte) (*ReviewResult, error) {
+	body, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("can't convert admission review to json: %s", err.Error())
+	}
+
+	admissionReviewRequest, err := decodeAdmissionReviewBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.decide(reqPath, admissionReviewRequest.Request)
+
+	resp, err := encodeAdmissionReview(admissionReviewRequest.APIVersion, result.response, result.warnings)
+	if err != nil {
+		return nil, fmt.Errorf("can't encode response: %s", err.Error())
+	}
+
+	review := &ReviewResult{
+		Response: resp,
+		Allowed:  result.response.Allowed,
+		Warnings: result.warnings,
+		Patch:    result.response.Patch,
+	}
+
+	if result.response.Result != nil {
+		review.Message = result.response.Result.Message
+	}
+
+	if len(review.Patch) > 0 && admissionReviewRequest.Request != nil {
+		patch, err := jsonpatch.DecodePatch(review.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("can't decode patch: %s", err.Error())
+		}
+
+		if review.Patched, err = patch.Apply(admissionReviewRequest.Request.Object.Raw); err != nil {
+			return nil, fmt.Errorf("can't apply patch: %s", err.Error())
+		}
+	}
+
+	return review, nil
+}
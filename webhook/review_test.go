@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+	cc "github.com/arutselvan15/estore-common/config"
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func TestServer_Review(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	create := loadSampleReview(t, sampleCreateFile, "admission.k8s.io/v1")
+	create = bytes.Replace(create, []byte("system:serviceaccount:kubernetes-dashboard:kubernetes-dashboard"),
+		[]byte("testuser"), 1)
+
+	createYAML, err := yaml.JSONToYAML(create)
+	if err != nil {
+		t.Fatalf("JSONToYAML() error = %v", err)
+	}
+
+	blacklisted := bytes.Replace(create, []byte("testuser"), []byte("stranger"), 1)
+
+	tests := []struct {
+		name    string
+		reqPath string
+		data    []byte
+		allowed bool
+		patched bool
+		wantErr bool
+	}{
+		{name: "success mutate json", reqPath: cfg.MutateURL, data: create, allowed: true, patched: true},
+		{name: "success mutate yaml", reqPath: cfg.MutateURL, data: createYAML, allowed: true, patched: true},
+		{name: "success validate", reqPath: cfg.ValidateURL, data: create, allowed: true},
+		{name: "failure black list user", reqPath: cfg.ValidateURL, data: blacklisted, allowed: false},
+		{name: "failure invalid review", reqPath: cfg.ValidateURL, data: []byte("[}"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+
+			got, err := s.Review(tt.reqPath, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Review() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			assert.Equal(t, tt.allowed, got.Allowed, got.Message)
+			assert.Equal(t, tt.patched, got.Patched != nil)
+
+			res := admissionReviewV1{}
+			if err := json.Unmarshal(got.Response, &res); err != nil {
+				t.Fatalf("Review() invalid response = %v", err)
+			}
+
+			assert.Equal(t, "admission.k8s.io/v1", res.APIVersion)
+
+			if tt.patched {
+				pdt := pdtv1.Product{}
+				if err := json.Unmarshal(got.Patched, &pdt); err != nil {
+					t.Fatalf("Review() invalid patched object = %v", err)
+				}
+
+				assert.True(t, strings.HasPrefix(pdt.Annotations[pdtv1.ProductAnnotationWebhookStatusKey], cfg.Mutated))
+			}
+		})
+	}
+}
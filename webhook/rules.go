@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// evaluateRules evaluate the rules applicable for the operation and return all failures
+func evaluateRules(pdt pdtv1.Product, operation string, rules []cfg.Rule) []violation {
+	var violations []violation
+
+	obj, err := toUnstructured(pdt)
+	if err != nil {
+		return []violation{{rule: "rules", causeType: metav1.CauseTypeUnexpectedServerResponse, message: err.Error()}}
+	}
+
+	for _, rule := range rules {
+		if !rule.AppliesTo(operation) {
+			continue
+		}
+
+		causeType := metav1.CauseTypeFieldValueInvalid
+		if value, found := lookupField(obj, rule.Field); !found || isEmptyValue(value) {
+			causeType = metav1.CauseTypeFieldValueRequired
+		}
+
+		violations = append(violations, newViolations(rule.Name, rule.Enforcement, rule.Field, causeType,
+			evaluateRule(obj, rule))...)
+	}
+
+	return violations
+}
+
+func evaluateRule(obj map[string]interface{}, rule cfg.Rule) []string {
+	var errors []string
+
+	value, found := lookupField(obj, rule.Field)
+
+	// empty optional fields have nothing to check
+	if !found || isEmptyValue(value) {
+		if rule.Required {
+			return []string{ruleMessage(rule, value, "%s is required", rule.Field)}
+		}
+
+		return nil
+	}
+
+	if rule.Regex != "" {
+		if err := checkPattern(rule, value, rule.Regex, true); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if rule.NotRegex != "" {
+		if err := checkPattern(rule, value, rule.NotRegex, false); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if rule.Min != nil || rule.Max != nil {
+		if err := checkBounds(rule, value); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if len(rule.Enum) > 0 {
+		if err := checkEnum(rule, value); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	return errors
+}
+
+func checkPattern(rule cfg.Rule, value interface{}, pattern string, mustMatch bool) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("unable to check %s pattern. %s", rule.Field, err.Error())
+	}
+
+	for _, item := range valueItems(value) {
+		if re.MatchString(item) != mustMatch {
+			if mustMatch {
+				return fmt.Errorf("%s", ruleMessage(rule, item, "%s %s does not match pattern %s", rule.Field, item, pattern))
+			}
+
+			return fmt.Errorf("%s", ruleMessage(rule, item, "%s %s must not match pattern %s", rule.Field, item, pattern))
+		}
+	}
+
+	return nil
+}
+
+func checkBounds(rule cfg.Rule, value interface{}) error {
+	var (
+		size float64
+		kind = "value"
+	)
+
+	switch v := value.(type) {
+	case float64:
+		size = v
+	case string:
+		size, kind = float64(len(v)), "length"
+	case []interface{}:
+		size, kind = float64(len(v)), "length"
+	case map[string]interface{}:
+		size, kind = float64(len(v)), "length"
+	default:
+		return fmt.Errorf("unable to check %s bounds for value %v", rule.Field, value)
+	}
+
+	if rule.Min != nil && size < *rule.Min {
+		return fmt.Errorf("%s", ruleMessage(rule, value, "%s %s %v is less than minimum %v", rule.Field, kind, size, *rule.Min))
+	}
+
+	if rule.Max != nil && size > *rule.Max {
+		return fmt.Errorf("%s", ruleMessage(rule, value, "%s %s %v is greater than maximum %v", rule.Field, kind, size, *rule.Max))
+	}
+
+	return nil
+}
+
+func checkEnum(rule cfg.Rule, value interface{}) error {
+	allowed := map[string]bool{}
+	for _, e := range rule.Enum {
+		allowed[e] = true
+	}
+
+	for _, item := range valueItems(value) {
+		if !allowed[item] {
+			return fmt.Errorf("%s", ruleMessage(rule, item, "%s %s is not one of %s", rule.Field, item,
+				strings.Join(rule.Enum, ", ")))
+		}
+	}
+
+	return nil
+}
+
+// ruleMessage custom rule message if configured else the default message
+func ruleMessage(rule cfg.Rule, value interface{}, format string, args ...interface{}) string {
+	if rule.Message == "" {
+		return fmt.Sprintf(format, args...)
+	}
+
+	if value == nil {
+		value = ""
+	}
+
+	return strings.NewReplacer("{name}", rule.Name, "{field}", rule.Field,
+		"{value}", fmt.Sprintf("%v", value)).Replace(rule.Message)
+}
+
+// toUnstructured product as json map so rules can address fields by their json path
+func toUnstructured(pdt pdtv1.Product) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+
+	data, err := json.Marshal(pdt)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert product for rules. %s", err.Error())
+	}
+
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("unable to convert product for rules. %s", err.Error())
+	}
+
+	return obj, nil
+}
+
+// lookupField resolve a dotted field path, map keys containing dots are addressed as labels[key]
+func lookupField(obj map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = obj
+
+	for _, segment := range splitFieldPath(path) {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = m[segment]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func splitFieldPath(path string) []string {
+	var segments []string
+
+	for path != "" {
+		switch {
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return append(segments, path[1:])
+			}
+
+			segments = append(segments, path[1:end])
+			path = strings.TrimPrefix(path[end+1:], ".")
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				return append(segments, path)
+			}
+
+			segments = append(segments, path[:end])
+			path = strings.TrimPrefix(path[end:], ".")
+		}
+	}
+
+	return segments
+}
+
+// valueItems string form of a value, lists are expanded to their items
+func valueItems(value interface{}) []string {
+	var items []string
+
+	switch v := value.(type) {
+	case []interface{}:
+		for _, i := range v {
+			items = append(items, fmt.Sprintf("%v", i))
+		}
+	default:
+		items = append(items, fmt.Sprintf("%v", v))
+	}
+
+	return items
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
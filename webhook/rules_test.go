@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func Test_evaluateRules_defaults(t *testing.T) {
+	rules, err := cfg.GetProductRules()
+	if err != nil {
+		t.Fatalf("GetProductRules() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pdtName string
+		brand   string
+		wantErr bool
+	}{
+		{name: "success valid brand", pdtName: "iphone", brand: "iphone", wantErr: false},
+		{name: "failure invalid brand", pdtName: "iphone", brand: "@phone", wantErr: true},
+		{name: "failure empty brand", pdtName: "iphone", brand: "", wantErr: true},
+		{name: "success valid name", pdtName: "iphone", brand: "apple", wantErr: false},
+		{name: "failure invalid name", pdtName: "kube-@phone", brand: "apple", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdt := createProduct("sample-ns", tt.pdtName, tt.brand)
+			if errs := evaluateRules(*pdt, cfg.Create, rules); (len(errs) > 0) != tt.wantErr {
+				t.Errorf("evaluateRules() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_evaluateRules(t *testing.T) {
+	pdt := createProduct("sample-ns", "iphone", "apple")
+	pdt.Labels["product.estore.com/brand"] = "apple"
+	pdt.Spec.Price = 100
+	pdt.Spec.Categories = []string{"cellphones", "electronics"}
+
+	tests := []struct {
+		name      string
+		operation string
+		rule      cfg.Rule
+		want      []string
+	}{
+		{
+			name: "success rule skipped for operation", operation: cfg.Delete,
+			rule: cfg.Rule{Field: "spec.description", Required: true},
+		},
+		{
+			name: "failure required", operation: cfg.Create,
+			rule: cfg.Rule{Field: "spec.description", Required: true},
+			want: []string{"spec.description is required"},
+		},
+		{
+			name: "success optional missing field", operation: cfg.Create,
+			rule: cfg.Rule{Field: "spec.description", Regex: "^[a-z]+$"},
+		},
+		{
+			name: "success label regex", operation: cfg.Update,
+			rule: cfg.Rule{Field: "metadata.labels[product.estore.com/brand]", Regex: "^apple$"},
+		},
+		{
+			name: "failure not regex", operation: cfg.Create,
+			rule: cfg.Rule{Field: "metadata.name", NotRegex: "^i"},
+			want: []string{"metadata.name iphone must not match pattern ^i"},
+		},
+		{
+			name: "failure invalid regex", operation: cfg.Create,
+			rule: cfg.Rule{Field: "metadata.name", Regex: "("},
+			want: []string{"unable to check metadata.name pattern. error parsing regexp: missing closing ): `(`"},
+		},
+		{
+			name: "failure price max", operation: cfg.Create,
+			rule: cfg.Rule{Field: "spec.price", Max: floatPtr(50)},
+			want: []string{"spec.price value 100 is greater than maximum 50"},
+		},
+		{
+			name: "failure categories min count", operation: cfg.Create,
+			rule: cfg.Rule{Field: "spec.categories", Min: floatPtr(3)},
+			want: []string{"spec.categories length 2 is less than minimum 3"},
+		},
+		{
+			name: "failure enum", operation: cfg.Create,
+			rule: cfg.Rule{Field: "spec.categories", Enum: []string{"cellphones"}},
+			want: []string{"spec.categories electronics is not one of cellphones"},
+		},
+		{
+			name: "failure custom message", operation: cfg.Create,
+			rule: cfg.Rule{Name: "brand", Field: "spec.brand", Enum: []string{"samsung"}, Message: "{name}: {field} {value} not sold"},
+			want: []string{"brand: spec.brand apple not sold"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationMessages(evaluateRules(*pdt, tt.operation, []cfg.Rule{tt.rule}))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluateRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_evaluateRules_config(t *testing.T) {
+	viper.Set("app.rules.product", []map[string]interface{}{
+		{"name": "display-name", "field": "spec.displayName", "required": true, "operations": []string{"CREATE"}},
+	})
+	defer viper.Set("app.rules.product", nil)
+
+	rules, err := cfg.GetProductRules()
+	if err != nil {
+		t.Fatalf("GetProductRules() error = %v", err)
+	}
+
+	pdt := createProduct("sample-ns", "iphone", "apple")
+	if got := evaluateRules(*pdt, cfg.Create, rules); len(got) != 1 {
+		t.Errorf("evaluateRules() = %v, want 1 error", got)
+	}
+
+	if got := evaluateRules(*pdt, cfg.Update, rules); len(got) != 0 {
+		t.Errorf("evaluateRules() = %v, want no error", got)
+	}
+}
+
+func Test_splitFieldPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "spec.brand", want: []string{"spec", "brand"}},
+		{path: "metadata.labels[product.estore.com/brand]", want: []string{"metadata", "labels", "product.estore.com/brand"}},
+		{path: "metadata.annotations[a.b/c].x", want: []string{"metadata", "annotations", "a.b/c", "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := splitFieldPath(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitFieldPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
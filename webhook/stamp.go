@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cv "github.com/arutselvan15/estore-common/validate"
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// stampTolerance how far a timestamp set by the mutating webhook may be from the validation time, it covers the
+// reinvocation of the mutating webhook and the clock skew between the replicas
+const stampTolerance = time.Minute
+
+// stampAnnotations created stamps on create and last updated stamps on update
+func stampAnnotations(operation, user string) map[string]string {
+	now := timeNow().UTC().Format(time.RFC3339)
+
+	switch strings.ToUpper(operation) {
+	case cfg.Create:
+		return map[string]string{cfg.CreatedByAnnotation: user, cfg.CreatedAtAnnotation: now}
+	case cfg.Update:
+		return map[string]string{cfg.LastUpdatedByAnnotation: user, cfg.LastUpdatedAtAnnotation: now}
+	default:
+		return nil
+	}
+}
+
+// createStamped check a create already carries the stamps of the user set at admission time, the api server may
+// reinvoke the mutating webhook within the same request
+func createStamped(pdt pdtv1.Product, operation, user string) bool {
+	if !strings.EqualFold(operation, cfg.Create) {
+		return false
+	}
+
+	annotations := pdt.GetAnnotations()
+
+	return annotations[cfg.CreatedByAnnotation] == user && admissionTime(annotations[cfg.CreatedAtAnnotation])
+}
+
+// validateStampAnnotations reject stamps that do not belong to the requesting user or that were changed after
+// creation, the mutating webhook sets them before validation so only forged values fail. A new timestamp must be the
+// admission time and can not be set at all when the product opts out of the mutation
+func validateStampAnnotations(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation, user string) []violation {
+	var (
+		violations  []violation
+		annotations = pdt.GetAnnotations()
+	)
+
+	forged := func(key, message string, args ...interface{}) {
+		violations = append(violations, violation{rule: "stamps", mode: cfg.EnforcementEnforce,
+			field: fmt.Sprintf("metadata.annotations[%s]", key), causeType: metav1.CauseTypeFieldValueInvalid,
+			message: fmt.Sprintf(message, args...)})
+	}
+
+	mutated, _ := cv.AdmissionRequired(pdtv1.ProductAnnotationWebhookMutateKey, &pdt.ObjectMeta)
+
+	stamped := func(key, value string) {
+		switch {
+		case !mutated:
+			forged(key, "annotation %s can not be set when the mutation is skipped", key)
+		case !admissionTime(value):
+			forged(key, "annotation %s %s is not the admission time", key, value)
+		}
+	}
+
+	switch strings.ToUpper(operation) {
+	case cfg.Create:
+		if by, ok := annotations[cfg.CreatedByAnnotation]; ok && by != user {
+			forged(cfg.CreatedByAnnotation, "annotation %s %s does not match user %s", cfg.CreatedByAnnotation, by, user)
+		}
+
+		if at, ok := annotations[cfg.CreatedAtAnnotation]; ok {
+			stamped(cfg.CreatedAtAnnotation, at)
+		}
+
+		for _, key := range []string{cfg.LastUpdatedByAnnotation, cfg.LastUpdatedAtAnnotation} {
+			if _, ok := annotations[key]; ok {
+				forged(key, "annotation %s can not be set on create", key)
+			}
+		}
+	case cfg.Update:
+		if oldPdt == nil {
+			return nil
+		}
+
+		oldAnnotations := oldPdt.GetAnnotations()
+
+		for _, key := range []string{cfg.CreatedByAnnotation, cfg.CreatedAtAnnotation} {
+			if annotations[key] != oldAnnotations[key] {
+				forged(key, "annotation %s can not be changed, old value %s, new value %s", key,
+					formatAnnotation(oldAnnotations, key), formatAnnotation(annotations, key))
+			}
+		}
+
+		by, ok := annotations[cfg.LastUpdatedByAnnotation]
+		if ok && by != user && by != oldAnnotations[cfg.LastUpdatedByAnnotation] {
+			forged(cfg.LastUpdatedByAnnotation, "annotation %s %s does not match user %s", cfg.LastUpdatedByAnnotation,
+				by, user)
+		}
+
+		if at, ok := annotations[cfg.LastUpdatedAtAnnotation]; ok && at != oldAnnotations[cfg.LastUpdatedAtAnnotation] {
+			stamped(cfg.LastUpdatedAtAnnotation, at)
+		}
+	}
+
+	return violations
+}
+
+// admissionTime check the timestamp is within the stamp tolerance of the validation time
+func admissionTime(value string) bool {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+
+	elapsed := timeNow().Sub(at)
+
+	return elapsed > -stampTolerance && elapsed < stampTolerance
+}
+
+func formatAnnotation(annotations map[string]string, key string) string {
+	if v, ok := annotations[key]; ok {
+		return v
+	}
+
+	return "<none>"
+}
@@ -0,0 +1,205 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	ccFake "github.com/arutselvan15/estore-common/clients/fake"
+	cc "github.com/arutselvan15/estore-common/config"
+	cv "github.com/arutselvan15/estore-common/validate"
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func Test_stampAnnotations(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2020, 2, 21, 12, 0, 0, 0, time.UTC) }
+
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		name      string
+		operation string
+		want      map[string]string
+	}{
+		{name: "success create", operation: cfg.Create, want: map[string]string{
+			cfg.CreatedByAnnotation: "testuser", cfg.CreatedAtAnnotation: "2020-02-21T12:00:00Z"}},
+		{name: "success update", operation: cfg.Update, want: map[string]string{
+			cfg.LastUpdatedByAnnotation: "testuser", cfg.LastUpdatedAtAnnotation: "2020-02-21T12:00:00Z"}},
+		{name: "success delete", operation: cfg.Delete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stampAnnotations(tt.operation, "testuser"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stampAnnotations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateStampAnnotations(t *testing.T) {
+	timeNow = func() time.Time { return time.Date(2020, 3, 1, 9, 0, 0, 0, time.UTC) }
+
+	defer func() { timeNow = time.Now }()
+
+	oldPdt := createProduct("sample-ns", "iphone", "apple")
+	oldPdt.Annotations[cfg.CreatedByAnnotation] = "creator"
+	oldPdt.Annotations[cfg.CreatedAtAnnotation] = "2020-02-21T12:00:00Z"
+	oldPdt.Annotations[cfg.LastUpdatedByAnnotation] = "editor"
+	oldPdt.Annotations[cfg.LastUpdatedAtAnnotation] = "2020-02-22T12:00:00Z"
+
+	created := createProduct("sample-ns", "iphone", "apple")
+	created.Annotations[cfg.CreatedByAnnotation] = "testuser"
+
+	forgedCreator := created.DeepCopy()
+	forgedCreator.Annotations[cfg.CreatedByAnnotation] = "admin"
+
+	createdWithUpdate := created.DeepCopy()
+	createdWithUpdate.Annotations[cfg.LastUpdatedByAnnotation] = "testuser"
+
+	updated := oldPdt.DeepCopy()
+	updated.Annotations[cfg.LastUpdatedByAnnotation] = "testuser"
+
+	unchanged := oldPdt.DeepCopy()
+
+	changedCreator := updated.DeepCopy()
+	changedCreator.Annotations[cfg.CreatedByAnnotation] = "testuser"
+
+	removedCreatedAt := updated.DeepCopy()
+	delete(removedCreatedAt.Annotations, cfg.CreatedAtAnnotation)
+
+	forgedUpdater := oldPdt.DeepCopy()
+	forgedUpdater.Annotations[cfg.LastUpdatedByAnnotation] = "admin"
+
+	createdAt := created.DeepCopy()
+	createdAt.Annotations[cfg.CreatedAtAnnotation] = "2020-03-01T09:00:10Z"
+
+	forgedCreatedAt := created.DeepCopy()
+	forgedCreatedAt.Annotations[cfg.CreatedAtAnnotation] = "2019-01-01T00:00:00Z"
+
+	notMutatedCreatedAt := createdAt.DeepCopy()
+	notMutatedCreatedAt.Annotations[pdtv1.ProductAnnotationWebhookMutateKey] = "false"
+
+	updatedAt := updated.DeepCopy()
+	updatedAt.Annotations[cfg.LastUpdatedAtAnnotation] = "2020-03-01T08:59:50Z"
+
+	forgedUpdatedAt := updated.DeepCopy()
+	forgedUpdatedAt.Annotations[cfg.LastUpdatedAtAnnotation] = "2020-02-28T00:00:00Z"
+
+	notMutatedUpdatedAt := updatedAt.DeepCopy()
+	notMutatedUpdatedAt.Annotations[pdtv1.ProductAnnotationWebhookMutateKey] = "false"
+
+	notMutated := unchanged.DeepCopy()
+	notMutated.Annotations[pdtv1.ProductAnnotationWebhookMutateKey] = "false"
+
+	tests := []struct {
+		name      string
+		operation string
+		pdt       *pdtv1.Product
+		want      []string
+	}{
+		{name: "success create stamped", operation: cfg.Create, pdt: created},
+		{name: "failure create forged creator", operation: cfg.Create, pdt: forgedCreator,
+			want: []string{"annotation product.estore.com/created-by admin does not match user testuser"}},
+		{name: "failure create with update stamp", operation: cfg.Create, pdt: createdWithUpdate,
+			want: []string{"annotation product.estore.com/last-updated-by can not be set on create"}},
+		{name: "success update stamped", operation: cfg.Update, pdt: updated},
+		{name: "success update not stamped", operation: cfg.Update, pdt: unchanged},
+		{name: "failure update changed creator", operation: cfg.Update, pdt: changedCreator,
+			want: []string{"annotation product.estore.com/created-by can not be changed, old value creator, new value testuser"}},
+		{name: "failure update removed created at", operation: cfg.Update, pdt: removedCreatedAt,
+			want: []string{"annotation product.estore.com/created-at can not be changed, old value 2020-02-21T12:00:00Z, new value <none>"}},
+		{name: "failure update forged updater", operation: cfg.Update, pdt: forgedUpdater,
+			want: []string{"annotation product.estore.com/last-updated-by admin does not match user testuser"}},
+		{name: "success create stamped at admission time", operation: cfg.Create, pdt: createdAt},
+		{name: "failure create forged created at", operation: cfg.Create, pdt: forgedCreatedAt,
+			want: []string{"annotation product.estore.com/created-at 2019-01-01T00:00:00Z is not the admission time"}},
+		{name: "failure create created at with mutation skipped", operation: cfg.Create, pdt: notMutatedCreatedAt,
+			want: []string{"annotation product.estore.com/created-at can not be set when the mutation is skipped"}},
+		{name: "success update stamped at admission time", operation: cfg.Update, pdt: updatedAt},
+		{name: "failure update forged last updated at", operation: cfg.Update, pdt: forgedUpdatedAt,
+			want: []string{"annotation product.estore.com/last-updated-at 2020-02-28T00:00:00Z is not the admission time"}},
+		{name: "failure update last updated at with mutation skipped", operation: cfg.Update, pdt: notMutatedUpdatedAt,
+			want: []string{"annotation product.estore.com/last-updated-at can not be set when the mutation is skipped"}},
+		{name: "success update not stamped with mutation skipped", operation: cfg.Update, pdt: notMutated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationMessages(validateStampAnnotations(*tt.pdt, oldPdt, tt.operation, "testuser"))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMutateProduct_stamps(t *testing.T) {
+	pdt := createProduct("sample-ns", "iphone", "apple")
+	pdt.Annotations[cfg.CreatedByAnnotation] = "admin"
+
+	data, err := MutateProduct(*pdt, nil, cfg.Create, "testuser")
+	if err != nil {
+		t.Fatalf("MutateProduct() error = %v", err)
+	}
+
+	var patch []cv.PatchOperation
+	if err := json.Unmarshal(data, &patch); err != nil {
+		t.Fatalf("MutateProduct() invalid patch = %v", err)
+	}
+
+	// a creator set by the user is overwritten
+	assert.Contains(t, patch, cv.PatchOperation{Op: "replace", Path: "/metadata/annotations/product.estore.com~1created-by", Value: "testuser"})
+}
+
+func TestServer_handle_replaceKeepsStamps(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+
+	stored := createProduct("sample-ns", "iphone", "apple")
+	stored.Annotations[cfg.CreatedByAnnotation] = "creator"
+	stored.Annotations[cfg.CreatedAtAnnotation] = "2020-02-21T12:00:00Z"
+
+	// a full replace rebuilds the product without the stamps
+	replaced := createProduct("sample-ns", "iphone", "apple")
+	replaced.Spec.DisplayName = "iPhone 11"
+
+	oldObj, _ := json.Marshal(stored)
+	ar := createAdmissionReview(replaced, "testuser", cfg.Update)
+	ar.Request.OldObject = runtime.RawExtension{Raw: oldObj}
+
+	result := s.handle(cfg.MutateURL, ar.Request)
+	if !assert.True(t, result.response.Allowed, result.response.Result) {
+		return
+	}
+
+	patch, err := jsonpatch.DecodePatch(result.response.Patch)
+	if err != nil {
+		t.Fatalf("handle() invalid patch = %v", err)
+	}
+
+	patched, err := patch.Apply(ar.Request.Object.Raw)
+	if err != nil {
+		t.Fatalf("handle() patch does not apply = %v", err)
+	}
+
+	mutated := pdtv1.Product{}
+	if err := json.Unmarshal(patched, &mutated); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "creator", mutated.Annotations[cfg.CreatedByAnnotation])
+	assert.Equal(t, "2020-02-21T12:00:00Z", mutated.Annotations[cfg.CreatedAtAnnotation])
+
+	// the mutated replace passes the stamp validation
+	ar.Request.Object = runtime.RawExtension{Raw: patched}
+
+	result = s.handle(cfg.ValidateURL, ar.Request)
+	assert.True(t, result.response.Allowed, result.response.Result)
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+const serviceAccountPrefix = "system:serviceaccount:"
+
+// subjectMatch entry of a subject list matching the request
+type subjectMatch struct {
+	list  string
+	field string
+	entry string
+	value string
+}
+
+func (m subjectMatch) String() string {
+	return fmt.Sprintf("%s.%s entry %s matched %s", m.list, m.field, m.entry, m.value)
+}
+
+// matchSubject first entry of the list matching the user, its groups, its service account namespace or the
+// request namespace, nil when none matches
+func matchSubject(list cfg.SubjectList, userInfo authenticationv1.UserInfo, namespace string) *subjectMatch {
+	candidates := []struct {
+		field   string
+		entries []string
+		values  []string
+	}{
+		{field: "users", entries: list.Users, values: []string{userInfo.Username}},
+		{field: "groups", entries: list.Groups, values: userInfo.Groups},
+		{field: "serviceAccountNamespaces", entries: list.ServiceAccountNamespaces,
+			values: []string{serviceAccountNamespace(userInfo.Username)}},
+		{field: "namespaces", entries: list.Namespaces, values: []string{namespace}},
+	}
+
+	for _, c := range candidates {
+		for _, value := range c.values {
+			if value == "" {
+				continue
+			}
+
+			for _, entry := range c.entries {
+				if matchEntry(list, entry, value) {
+					return &subjectMatch{list: list.Name, field: c.field, entry: entry, value: value}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchEntry value entries of the lists granting a privilege match exactly, those of the other lists as prefixes
+func matchEntry(list cfg.SubjectList, entry, value string) bool {
+	if list.Exact {
+		return cfg.MatchExactEntry(entry, value)
+	}
+
+	return cfg.MatchEntry(entry, value)
+}
+
+// serviceAccountNamespace namespace of a system:serviceaccount:<namespace>:<name> user, empty for other users
+func serviceAccountNamespace(username string) string {
+	if !strings.HasPrefix(username, serviceAccountPrefix) {
+		return ""
+	}
+
+	parts := strings.Split(strings.TrimPrefix(username, serviceAccountPrefix), ":")
+	if len(parts) != 2 {
+		return ""
+	}
+
+	return parts[0]
+}
+
+// blacklistMessage denial message of a black list match
+func blacklistMessage(m subjectMatch, userInfo authenticationv1.UserInfo) string {
+	switch m.field {
+	case "namespaces":
+		return fmt.Sprintf("namedpace %s is black listed", m.value)
+	case "groups":
+		return fmt.Sprintf("group %s of user %s is black listed", m.value, userInfo.Username)
+	default:
+		return fmt.Sprintf("user %s is black listed", userInfo.Username)
+	}
+}
+
+// userInGroups user belongs to one of the groups, the groups are matched like the privileged list entries so that
+// the override and allowed groups take exact values, globs and regular expressions
+func userInGroups(userInfo authenticationv1.UserInfo, groups []string) bool {
+	return matchSubject(cfg.SubjectList{Name: "groups", Groups: groups, Exact: true}, userInfo, "") != nil
+}
+
+// freezeBypassed request of a privileged subject is not stopped by an active freeze window
+func freezeBypassed(req *v1beta1.AdmissionRequest) bool {
+	privileged := matchSubject(cfg.GetSubjectList(cfg.SubjectListPrivileged), req.UserInfo, req.Namespace)
+	if privileged == nil {
+		return false
+	}
+
+	log.Infof("freeze window bypassed by user %s, %s", req.UserInfo.Username, privileged)
+
+	return true
+}
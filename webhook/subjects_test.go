@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+func Test_matchSubject(t *testing.T) {
+	list := cfg.SubjectList{
+		Name:                     "blacklist",
+		Users:                    []string{"stranger", "ci-*", "/^bot-[0-9]+$/", "/[/"},
+		Groups:                   []string{"estore:suspended"},
+		ServiceAccountNamespaces: []string{"sandbox-?"},
+		Namespaces:               []string{"virus"},
+	}
+
+	tests := []struct {
+		name      string
+		user      string
+		groups    []string
+		namespace string
+		want      string
+	}{
+		{name: "success no match", user: "testuser", groups: []string{"system:authenticated"}, namespace: "store"},
+		{name: "success exact user", user: "stranger", want: "blacklist.users entry stranger matched stranger"},
+		{name: "success prefix user", user: "stranger-danger", want: "blacklist.users entry stranger matched stranger-danger"},
+		{name: "success glob user", user: "ci-runner", want: "blacklist.users entry ci-* matched ci-runner"},
+		{name: "success regex user", user: "bot-42", want: "blacklist.users entry /^bot-[0-9]+$/ matched bot-42"},
+		{name: "success regex not matched", user: "bot-x"},
+		{
+			name: "success group", user: "testuser", groups: []string{"system:authenticated", "estore:suspended"},
+			want: "blacklist.groups entry estore:suspended matched estore:suspended",
+		},
+		{
+			name: "success service account namespace", user: "system:serviceaccount:sandbox-1:importer",
+			want: "blacklist.serviceAccountNamespaces entry sandbox-? matched sandbox-1",
+		},
+		{name: "success service account other namespace", user: "system:serviceaccount:sandbox-12:importer"},
+		{name: "success request namespace", user: "testuser", namespace: "virus-lab", want: "blacklist.namespaces entry virus matched virus-lab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchSubject(list, authenticationv1.UserInfo{Username: tt.user, Groups: tt.groups}, tt.namespace)
+			if tt.want == "" {
+				assert.Nil(t, got)
+				return
+			}
+
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func Test_matchSubject_exact(t *testing.T) {
+	list := cfg.SubjectList{Name: "privileged", Users: []string{"release-bot"}, Groups: []string{"estore:release-*"},
+		Exact: true}
+
+	match := func(user string, groups ...string) bool {
+		return matchSubject(list, authenticationv1.UserInfo{Username: user, Groups: groups}, "") != nil
+	}
+
+	assert.True(t, match("release-bot"))
+	assert.False(t, match("release-bot-2"), "value entries of privileged lists are not prefixes")
+	assert.True(t, match("testuser", "estore:release-managers"), "globs still match")
+}
+
+func Test_serviceAccountNamespace(t *testing.T) {
+	assert.Equal(t, "kube-system", serviceAccountNamespace("system:serviceaccount:kube-system:admin"))
+	assert.Equal(t, "", serviceAccountNamespace("system:serviceaccount:kube-system"))
+	assert.Equal(t, "", serviceAccountNamespace("testuser"))
+}
+
+func Test_userInGroups(t *testing.T) {
+	groups := []string{"system:masters", "estore:release-*", "/^estore:team-[0-9]+$/"}
+
+	tests := []struct {
+		name   string
+		groups []string
+		want   bool
+	}{
+		{name: "success exact group", groups: []string{"system:authenticated", "system:masters"}, want: true},
+		{name: "failure group with the same prefix", groups: []string{"system:masters-readonly"}},
+		{name: "success glob group", groups: []string{"estore:release-managers"}, want: true},
+		{name: "success regex group", groups: []string{"estore:team-42"}, want: true},
+		{name: "failure regex group", groups: []string{"estore:team-a"}},
+		{name: "failure no group", groups: []string{"system:authenticated"}},
+		{name: "failure without groups"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := userInGroups(authenticationv1.UserInfo{Username: "testuser", Groups: tt.groups}, groups)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -2,58 +2,104 @@
 package webhook
 
 import (
-	"fmt"
-	"regexp"
+	"net/http"
 	"strings"
 
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
 
 	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
 )
 
-func validateProduct(pdt pdtv1.Product, operation, user string) error {
-	var errors []string
+const productKind = "Product"
+
+// validationError product validation failures with the field causes, the message joins all failures
+type validationError struct {
+	message string
+	causes  []metav1.StatusCause
+}
+
+func (e *validationError) Error() string {
+	return e.message
+}
 
-	if user == "" {
-		errors = append(errors, "user not found in request")
+// status invalid status of the product with the field causes
+func (e *validationError) status(pdt pdtv1.Product) *metav1.Status {
+	return &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: e.message,
+		Reason:  metav1.StatusReasonInvalid,
+		Code:    http.StatusUnprocessableEntity,
+		Details: &metav1.StatusDetails{
+			Name:   pdt.Name,
+			Group:  pdtv1.SchemeGroupVersion.Group,
+			Kind:   productKind,
+			Causes: e.causes,
+		},
 	}
+}
 
-	switch strings.ToLower(operation) {
-	case strings.ToLower(cfg.Create), strings.ToLower(cfg.Update):
-		if err := validateName(pdt.Name); err != nil {
-			errors = append(errors, err.Error())
-		}
+// validateProduct validate the product and return the warnings of the violations not enforced, catalog holds
+// the violations found against the existing products
+func validateProduct(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation string,
+	userInfo authenticationv1.UserInfo, catalog []violation) ([]string, error) {
+	var (
+		errors     []violation
+		violations = catalog
+	)
 
-		if err := validateBrand(pdt.Spec.Brand); err != nil {
-			errors = append(errors, err.Error())
-		}
+	if userInfo.Username == "" {
+		errors = append(errors, violation{field: "userInfo.username", causeType: metav1.CauseTypeFieldValueRequired,
+			message: "user not found in request"})
 	}
 
-	if errors != nil {
-		return fmt.Errorf(strings.Join(errors, ". "))
+	rules, err := cfg.GetProductRules()
+	if err != nil {
+		errors = append(errors, violation{rule: "rules", causeType: metav1.CauseTypeUnexpectedServerResponse,
+			message: err.Error()})
+	} else {
+		violations = append(violations, evaluateRules(pdt, operation, rules)...)
 	}
 
-	return nil
-}
+	if !strings.EqualFold(operation, cfg.Delete) {
+		violations = append(violations, newViolations("price", "", "spec.price", metav1.CauseTypeFieldValueInvalid,
+			validatePrice(pdt, oldPdt))...)
+		violations = append(violations, newViolations("categories", "", "spec.categories",
+			metav1.CauseTypeFieldValueInvalid, validateCategories(pdt.Spec.Categories))...)
+	}
 
-func validateName(name string) error {
-	if strings.HasPrefix(name, "kube-") {
-		return fmt.Errorf("metadata.name %s with prefix kube- is not allowed", name)
+	if strings.EqualFold(operation, cfg.Update) && oldPdt != nil {
+		violations = append(violations, validateImmutableFields(pdt, *oldPdt, userInfo)...)
 	}
 
-	return nil
-}
+	violations = append(violations, validateProtection(pdt, oldPdt, operation, userInfo)...)
 
-func validateBrand(brand string) error {
-	// match alphabets and - only
-	ok, err := regexp.MatchString("^([a-zA-Z-]+$)", brand)
-	if err != nil {
-		return fmt.Errorf("unable to check spec.brand name pattern. %s", err.Error())
+	violations = append(violations, validateStampAnnotations(pdt, oldPdt, operation, userInfo.Username)...)
+
+	enforced, warnings := enforce(pdt, violations)
+	errors = append(errors, enforced...)
+
+	if !strings.EqualFold(operation, cfg.Delete) {
+		warnings = append(warnings, softViolations(pdt)...)
+	}
+
+	if errors != nil {
+		return warnings, newValidationError(errors)
 	}
 
-	if !ok {
-		return fmt.Errorf("spec.brand %s is not valid", brand)
+	return warnings, nil
+}
+
+func newValidationError(violations []violation) *validationError {
+	messages := make([]string, 0, len(violations))
+	causes := make([]metav1.StatusCause, 0, len(violations))
+
+	for _, v := range violations {
+		messages = append(messages, v.message)
+		causes = append(causes, metav1.StatusCause{Type: v.causeType, Message: v.message, Field: v.field})
 	}
 
-	return nil
+	return &validationError{message: strings.Join(messages, ". "), causes: causes}
 }
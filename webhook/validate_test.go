@@ -1,8 +1,11 @@
 package webhook
 
 import (
+	"net/http"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	v1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
@@ -10,58 +13,6 @@ import (
 	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
 )
 
-func Test_validateBrand(t *testing.T) {
-	type args struct {
-		brand string
-	}
-
-	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-	}{
-		{
-			name: "success valid brand", args: args{brand: "iphone"}, wantErr: false,
-		}, {
-			name: "failure invalid brand", args: args{brand: "@phone"}, wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if err := validateBrand(tt.args.brand); (err != nil) != tt.wantErr {
-				t.Errorf("validateBrand() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
-func Test_validateName(t *testing.T) {
-	type args struct {
-		name string
-	}
-
-	tests := []struct {
-		name    string
-		args    args
-		wantErr bool
-	}{
-		{
-			name: "success valid name", args: args{name: "iphone"}, wantErr: false,
-		}, {
-			name: "failure invalid name", args: args{name: "kube-@phone"}, wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if err := validateName(tt.args.name); (err != nil) != tt.wantErr {
-				t.Errorf("validateName() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
 func Test_validateProduct(t *testing.T) {
 	type args struct {
 		operation string
@@ -71,12 +22,12 @@ func Test_validateProduct(t *testing.T) {
 
 	pdtErr := v1.Product{
 		ObjectMeta: metav1.ObjectMeta{Name: "kube-iphone"},
-		Spec:       v1.ProductSpec{Brand: "@iphone"},
+		Spec:       v1.ProductSpec{Brand: "@iphone", Price: 100},
 	}
 
 	pdtOk := v1.Product{
 		ObjectMeta: metav1.ObjectMeta{Name: "iphone"},
-		Spec:       v1.ProductSpec{Brand: "iphone"},
+		Spec:       v1.ProductSpec{Brand: "iphone", Price: 100},
 	}
 
 	tests := []struct {
@@ -95,9 +46,34 @@ func Test_validateProduct(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := validateProduct(tt.args.pdt, tt.args.operation, tt.args.user); (err != nil) != tt.wantErr {
+			userInfo := authenticationv1.UserInfo{Username: tt.args.user}
+			if _, err := validateProduct(tt.args.pdt, nil, tt.args.operation, userInfo, nil); (err != nil) != tt.wantErr {
 				t.Errorf("validateProduct() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_validateProduct_causes(t *testing.T) {
+	pdt := v1.Product{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-iphone"},
+		Spec:       v1.ProductSpec{Brand: "@iphone", Price: 100},
+	}
+
+	_, err := validateProduct(pdt, nil, cfg.Create, authenticationv1.UserInfo{Username: "system"}, nil)
+
+	vErr, ok := err.(*validationError)
+	if !ok {
+		t.Fatalf("validateProduct() error = %v, want validation error", err)
+	}
+
+	status := vErr.status(pdt)
+	assert.Equal(t, metav1.StatusReasonInvalid, status.Reason)
+	assert.Equal(t, int32(http.StatusUnprocessableEntity), status.Code)
+	assert.Equal(t, "metadata.name kube-iphone with prefix kube- is not allowed. spec.brand @iphone is not valid", status.Message)
+	assert.Equal(t, "kube-iphone", status.Details.Name)
+	assert.Equal(t, []metav1.StatusCause{
+		{Type: metav1.CauseTypeFieldValueInvalid, Field: "metadata.name", Message: "metadata.name kube-iphone with prefix kube- is not allowed"},
+		{Type: metav1.CauseTypeFieldValueInvalid, Field: "spec.brand", Message: "spec.brand @iphone is not valid"},
+	}, status.Details.Causes)
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
+
+	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+)
+
+// softViolations non blocking findings returned as admission warnings
+func softViolations(pdt pdtv1.Product) []string {
+	var warnings []string
+
+	if strings.TrimSpace(pdt.Spec.Description) == "" {
+		warnings = append(warnings, "spec.description is empty")
+	}
+
+	if strings.EqualFold(strings.TrimSpace(pdt.Spec.DisplayName), pdt.Name) {
+		warnings = append(warnings, fmt.Sprintf("spec.displayName %s is the same as metadata.name",
+			pdt.Spec.DisplayName))
+	}
+
+	deprecated := cfg.GetCategoryPolicy().Deprecated
+
+	for _, c := range pdt.Spec.Categories {
+		if categoryDeprecated(normalizeCategory(c), deprecated) {
+			warnings = append(warnings, fmt.Sprintf("spec.categories %s is deprecated", c))
+		}
+	}
+
+	return warnings
+}
+
+// categoryDeprecated category or one of its parents is deprecated
+func categoryDeprecated(category string, deprecated []string) bool {
+	for _, d := range deprecated {
+		if categoryWithin(category, normalizeCategory(d)) {
+			return true
+		}
+	}
+
+	return false
+}
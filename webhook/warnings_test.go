@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func Test_softViolations(t *testing.T) {
+	viper.Set("app.categories.deprecated", "cellphones, home")
+	defer viper.Set("app.categories.deprecated", nil)
+
+	tests := []struct {
+		name        string
+		displayName string
+		description string
+		categories  []string
+		want        []string
+	}{
+		{name: "success no findings", displayName: "iPhone X", description: "phone"},
+		{name: "success missing description", displayName: "iPhone X", want: []string{"spec.description is empty"}},
+		{name: "success display name same as name", displayName: "IPhone", description: "phone", want: []string{"spec.displayName IPhone is the same as metadata.name"}},
+		{
+			name: "success deprecated categories", displayName: "iPhone X", description: "phone",
+			categories: []string{"Cellphones", "home/kitchen", "electronics/cellphones"},
+			want:       []string{"spec.categories Cellphones is deprecated", "spec.categories home/kitchen is deprecated"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdt := createProduct("sample-ns", "iphone", "apple")
+			pdt.Spec.DisplayName = tt.displayName
+			pdt.Spec.Description = tt.description
+			pdt.Spec.Categories = tt.categories
+
+			if got := softViolations(*pdt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("softViolations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
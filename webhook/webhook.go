@@ -8,8 +8,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -21,6 +23,7 @@ import (
 
 	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
 	cLog "github.com/arutselvan15/estore-product-kube-webhook/log"
+	"github.com/arutselvan15/estore-product-kube-webhook/metrics"
 )
 
 var (
@@ -33,51 +36,42 @@ var (
 // Server server
 type Server struct {
 	Clients cc.EstoreClientInterface
+	// Products cached products for validations across products, live calls with Clients when nil
+	Products ProductLister
+}
+
+// admissionResult admission response with the metrics decision and the warnings v1beta1 can not carry
+type admissionResult struct {
+	response *v1beta1.AdmissionResponse
+	warnings []string
+	decision string
 }
 
 // Serve serve
 func (s Server) Serve(httpWriter http.ResponseWriter, httpReq *http.Request) {
-	var (
-		admissionReviewResponse = v1beta1.AdmissionReview{}
-		admissionResponse       = &v1beta1.AdmissionResponse{
-			Allowed: false,
-			Result:  &metav1.Status{},
-		}
-	)
+	start := time.Now()
+
+	// chunked requests have no content length, the bytes read are observed instead
+	body := &countingReader{reader: httpReq.Body}
 
-	admissionReviewRequest, err := decodeAdmissionReview(httpReq.Body)
+	defer func() { metrics.ObserveAdmission(httpReq.URL.Path, start, body.count) }()
+
+	admissionReviewRequest, err := decodeAdmissionReview(body)
 	if err != nil {
 		handleError(httpWriter, fmt.Errorf("empty body.  %s", err.Error()), http.StatusBadRequest)
 
 		return
 	}
 
-	req := admissionReviewRequest.Request
-
-	if req != nil {
-		if cv.CheckBlacklistUser(req.UserInfo.Username) {
-			admissionResponse.Result.Message = fmt.Sprintf("user %s is black listed", req.UserInfo.Username)
-		} else if cv.CheckBlacklistNamespace(req.Namespace) {
-			admissionResponse.Result.Message = fmt.Sprintf("namedpace %s is black listed", req.Namespace)
-		} else if cv.CheckSystemUser(req.UserInfo.Username) || cv.CheckSystemNamespace(req.Namespace) {
-			admissionResponse.Allowed = true
-		} else {
-			admissionResponse = s.handle(httpReq.URL.Path, req)
-		}
-	} else {
-		admissionResponse.Result.Message = fmt.Sprintf("request is empty")
-	}
-
-	if admissionResponse != nil {
-		admissionReviewResponse.Response = admissionResponse
-		if admissionReviewRequest != nil && admissionReviewRequest.Request != nil {
-			admissionReviewResponse.Response.UID = admissionReviewRequest.Request.UID
-		}
-	}
+	result := s.decide(httpReq.URL.Path, admissionReviewRequest.Request)
+	admissionResponse := result.response
 
-	resp, err := json.Marshal(admissionReviewResponse)
+	// reply in the admission review version the api server sent
+	resp, err := encodeAdmissionReview(admissionReviewRequest.APIVersion, admissionResponse, result.warnings)
 	if err != nil {
 		handleError(httpWriter, fmt.Errorf("can't encode response: %v", err), http.StatusInternalServerError)
+
+		return
 	}
 
 	if _, err := httpWriter.Write(resp); err != nil {
@@ -91,12 +85,54 @@ func (s Server) Serve(httpWriter http.ResponseWriter, httpReq *http.Request) {
 	}
 }
 
-func (s Server) handle(reqPath string, req *v1beta1.AdmissionRequest) *v1beta1.AdmissionResponse {
+// decide run the request through the black list, system, freeze and product checks of the path
+func (s Server) decide(reqPath string, req *v1beta1.AdmissionRequest) admissionResult {
+	result := admissionResult{
+		response: &v1beta1.AdmissionResponse{Allowed: false, Result: &metav1.Status{}},
+		decision: metrics.Denied,
+	}
+
+	if req == nil {
+		result.response.Result.Message = fmt.Sprintf("request is empty")
+		metrics.RecordAdmission(reqPath, "", "", result.decision)
+
+		return result
+	}
+
+	blacklisted := matchSubject(cfg.GetSubjectList(cfg.SubjectListBlacklist), req.UserInfo, req.Namespace)
+	system := matchSubject(cfg.GetSubjectList(cfg.SubjectListSystem), req.UserInfo, req.Namespace)
+
+	if blacklisted != nil {
+		log.Infof("request of user %s denied, %s", req.UserInfo.Username, blacklisted)
+		result.response.Result.Message = blacklistMessage(*blacklisted, req.UserInfo)
+		result.decision = metrics.Blacklisted
+	} else if system != nil {
+		log.Infof("request of user %s allowed, %s", req.UserInfo.Username, system)
+		result.response.Allowed = true
+		result.decision = metrics.SystemBypass
+	} else if frozen, msg := checkFreeze(reqPath, string(req.Operation)); frozen && !freezeBypassed(req) {
+		result.response.Result.Message = msg
+	} else {
+		result = s.handle(reqPath, req)
+	}
+
+	metrics.RecordAdmission(reqPath, string(req.Operation), req.Namespace, result.decision)
+
+	if result.response != nil {
+		result.response.UID = req.UID
+	}
+
+	return result
+}
+
+func (s Server) handle(reqPath string, req *v1beta1.AdmissionRequest) admissionResult {
 	var (
 		pdt        pdtv1.Product
-		oldPdt     pdtv1.Product
+		oldPdt     *pdtv1.Product
 		patchBytes []byte
+		warnings   []string
 		err        error
+		decision   = metrics.Denied
 
 		response = &v1beta1.AdmissionResponse{Allowed: false, Result: &metav1.Status{}}
 	)
@@ -115,8 +151,16 @@ func (s Server) handle(reqPath string, req *v1beta1.AdmissionRequest) *v1beta1.A
 			req.UserInfo.Username).Infof("admission review for namespace=%s, name=%s, user=%s, operation=%s",
 			req.Namespace, req.Name, req.UserInfo.Username, req.Operation)
 
+		// the old product of an update carries the stored stamps and the opt outs
+		if strings.EqualFold(string(req.Operation), cfg.Update) {
+			oldPdt = &pdtv1.Product{}
+			if json.Unmarshal(oldObjBytes, oldPdt) != nil {
+				oldPdt = nil
+			}
+		}
+
 		if reqPath == cfg.MutateURL {
-			patchBytes, err = s.mutate(pdt, string(req.Operation), req.UserInfo.Username)
+			patchBytes, err = s.mutate(pdt, oldPdt, string(req.Operation), req.UserInfo.Username)
 			if err == nil {
 				response.Patch = patchBytes
 				response.PatchType = func() *v1beta1.PatchType {
@@ -125,17 +169,13 @@ func (s Server) handle(reqPath string, req *v1beta1.AdmissionRequest) *v1beta1.A
 				}()
 			}
 		} else if reqPath == cfg.ValidateURL {
-			if strings.EqualFold(string(req.Operation), cfg.Update) {
-				if err = json.Unmarshal(oldObjBytes, &oldPdt); err != nil {
-					log.LogAuditObject(pdt)
-				} else {
-					log.LogAuditObject(oldPdt, pdt)
-				}
+			if oldPdt != nil {
+				log.LogAuditObject(*oldPdt, pdt)
 			} else {
 				log.LogAuditObject(pdt)
 			}
 
-			err = s.validate(pdt, string(req.Operation), req.UserInfo.Username)
+			warnings, err = s.validate(pdt, oldPdt, string(req.Operation), req.UserInfo)
 		} else {
 			err = fmt.Errorf("invalid request path %s", reqPath)
 		}
@@ -143,15 +183,50 @@ func (s Server) handle(reqPath string, req *v1beta1.AdmissionRequest) *v1beta1.A
 		if err != nil {
 			log.SetStepState(lc.Error).Error(err.Error())
 			response.Result.Message = err.Error()
+
+			if vErr, ok := err.(*validationError); ok {
+				response.Result = vErr.status(pdt)
+			}
 		} else {
 			response.Allowed = true
+			decision = admissionDecision(reqPath, pdt, oldPdt)
 		}
 	}
 
-	return response
+	return admissionResult{response: response, warnings: warnings, decision: decision}
+}
+
+// admissionDecision allowed or skipped when the product opts out with the webhook annotation
+func admissionDecision(reqPath string, pdt pdtv1.Product, oldPdt *pdtv1.Product) string {
+	key := pdtv1.ProductAnnotationWebhookValidateKey
+	if reqPath == cfg.MutateURL {
+		key = pdtv1.ProductAnnotationWebhookMutateKey
+	}
+
+	if required, _ := admissionRequired(key, pdt, oldPdt); !required {
+		return metrics.Skipped
+	}
+
+	return metrics.Allowed
+}
+
+// admissionRequired check the product does not opt out with the webhook annotation, an update only honors the
+// opt out when the old product already had it so that it can not be set together with a guarded change
+func admissionRequired(key string, pdt pdtv1.Product, oldPdt *pdtv1.Product) (bool, string) {
+	required, msg := cv.AdmissionRequired(key, &pdt.ObjectMeta)
+	if required || oldPdt == nil {
+		return required, msg
+	}
+
+	if oldRequired, _ := cv.AdmissionRequired(key, &oldPdt.ObjectMeta); oldRequired {
+		log.Infof("annotation %s added by the update of %s is not honored", key, pdt.Name)
+		return true, ""
+	}
+
+	return false, msg
 }
 
-func (s Server) mutate(pdt pdtv1.Product, operation, user string) ([]byte, error) {
+func (s Server) mutate(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation, user string) ([]byte, error) {
 	var (
 		patchBytes []byte
 		err        error
@@ -165,7 +240,7 @@ func (s Server) mutate(pdt pdtv1.Product, operation, user string) ([]byte, error
 	if !required {
 		log.SetStepState(lc.Skip).Info(msg)
 	} else {
-		patchBytes, err = MutateProduct(pdt, operation, user)
+		patchBytes, err = MutateProduct(pdt, oldPdt, operation, user)
 
 		if err == nil {
 			if patchBytes != nil {
@@ -179,24 +254,42 @@ func (s Server) mutate(pdt pdtv1.Product, operation, user string) ([]byte, error
 	return patchBytes, err
 }
 
-func (s Server) validate(pdt pdtv1.Product, operation, user string) error {
+func (s Server) validate(pdt pdtv1.Product, oldPdt *pdtv1.Product, operation string,
+	userInfo authenticationv1.UserInfo) ([]string, error) {
+	var warnings []string
+
 	log.SetStep(lc.Validate).SetStepState(lc.Start).Infof(
 		"========== validate namespace=%s, name=%s, operation=%s ==========", pdt.Namespace, pdt.Name, operation)
 
-	required, msg := cv.AdmissionRequired(pdtv1.ProductAnnotationWebhookValidateKey, &pdt.ObjectMeta)
+	required, msg := admissionRequired(pdtv1.ProductAnnotationWebhookValidateKey, pdt, oldPdt)
+
+	// the duplicates and quotas are checked whatever the opt out so that imports can not flood the catalog
+	catalog := append(s.findDuplicates(pdt, oldPdt, operation), s.checkQuota(pdt, operation)...)
 
 	if !required {
 		log.SetStepState(lc.Skip).Info(msg)
-	} else {
-		err := validateProduct(pdt, operation, user)
 
+		// the opt out does not lift the deletion protection nor allow forged stamps
+		guards := append(catalog, validateProtection(pdt, oldPdt, operation, userInfo)...)
+		guards = append(guards, validateStampAnnotations(pdt, oldPdt, operation, userInfo.Username)...)
+
+		errors, w := enforce(pdt, guards)
+		if errors != nil {
+			return w, newValidationError(errors)
+		}
+
+		warnings = w
+	} else {
+		w, err := validateProduct(pdt, oldPdt, operation, userInfo, catalog)
 		if err != nil {
-			return err
+			return w, err
 		}
+
+		warnings = w
 		log.SetObjectState(lc.Received).LogAuditObject(pdt)
 	}
 
-	return nil
+	return warnings, nil
 }
 
 func decodeAdmissionReview(httpBody io.Reader) (*v1beta1.AdmissionReview, error) {
@@ -213,16 +306,28 @@ func decodeAdmissionReview(httpBody io.Reader) (*v1beta1.AdmissionReview, error)
 	}
 
 	// decode request
-	admissionReviewReceived := &v1beta1.AdmissionReview{}
-	if _, _, err := deserializer.Decode(body, nil, admissionReviewReceived); err != nil {
-		// error decoding the request
-		return nil, fmt.Errorf("can't decode body: %s", err.Error())
+	return decodeAdmissionReviewBody(body)
+}
+
+// countingReader reader counting the bytes read
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if r.reader == nil {
+		return 0, io.EOF
 	}
 
-	return admissionReviewReceived, nil
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+
+	return n, err
 }
 
 func handleError(rWriter http.ResponseWriter, err error, errCode int) {
-	log.SetStepState(lc.Error).Errorf(err.Error())
+	metrics.RecordError(errCode)
+	log.SetStepState(lc.Error).Error(err.Error())
 	http.Error(rWriter, fmt.Sprintf("error occurred: %v", err), errCode)
 }
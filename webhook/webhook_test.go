@@ -26,6 +26,7 @@ import (
 	pdtv1 "github.com/arutselvan15/estore-product-kube-client/pkg/apis/estore/v1"
 
 	cfg "github.com/arutselvan15/estore-product-kube-webhook/config"
+	"github.com/arutselvan15/estore-product-kube-webhook/metrics"
 )
 
 func createProduct(namespace, name, brand string) *pdtv1.Product {
@@ -38,6 +39,7 @@ func createProduct(namespace, name, brand string) *pdtv1.Product {
 		},
 		Spec: pdtv1.ProductSpec{
 			Brand: brand,
+			Price: 100,
 		},
 	}
 
@@ -183,11 +185,18 @@ func TestServer_validate(t *testing.T) {
 		operation string
 		user      string
 		pdt       pdtv1.Product
+		oldPdt    *pdtv1.Product
 	}
 
 	pdt := createProduct("sample-ns", "sample-prd", "apple")
 	invalidName := createProduct("sample-ns", "kube-sample-prd", "apple")
 	invalidBrand := createProduct("sample-ns", "sample-prd", "1apple")
+	noPrice := createProduct("sample-ns", "sample-prd", "apple")
+	noPrice.Spec.Price = 0
+	noPriceLabeled := noPrice.DeepCopy()
+	noPriceLabeled.Labels["tier"] = "clearance"
+	noPriceLabeled.Finalizers = nil
+	noPrice.Finalizers = []string{"estore.com/cleanup"}
 	fClient := ccFake.NewEstoreFakeClientForConfig(nil, nil)
 
 	tests := []struct {
@@ -205,6 +214,12 @@ func TestServer_validate(t *testing.T) {
 		{
 			name: "failure validate pdt invalid brand", fields: fields{Clients: fClient}, args: args{operation: cfg.Create, pdt: *invalidBrand, user: "system"}, wantErr: true,
 		},
+		{
+			name: "success metadata update of pdt without price", fields: fields{Clients: fClient}, args: args{operation: cfg.Update, pdt: *noPriceLabeled, oldPdt: noPrice, user: "system"}, wantErr: false,
+		},
+		{
+			name: "failure create pdt without price", fields: fields{Clients: fClient}, args: args{operation: cfg.Create, pdt: *noPrice, user: "system"}, wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -212,7 +227,7 @@ func TestServer_validate(t *testing.T) {
 			s := Server{
 				Clients: tt.fields.Clients,
 			}
-			err := s.validate(tt.args.pdt, tt.args.operation, tt.args.user)
+			_, err := s.validate(tt.args.pdt, tt.args.oldPdt, tt.args.operation, authenticationv1.UserInfo{Username: tt.args.user})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -248,7 +263,7 @@ func TestServer_mutate(t *testing.T) {
 			name: "success mutate pdt", fields: fields{Clients: fClient}, args: args{operation: cfg.Create, pdt: *pdt, user: "system"}, want: true, wantErr: false,
 		},
 		{
-			name: "success already mutate pdt", fields: fields{Clients: fClient}, args: args{operation: cfg.Update, pdt: *alreadyMutatedPdt, user: "system"}, want: false, wantErr: false,
+			name: "success stamp update of already mutated pdt", fields: fields{Clients: fClient}, args: args{operation: cfg.Update, pdt: *alreadyMutatedPdt, user: "system"}, want: true, wantErr: false,
 		},
 		{
 			name: "success no mutate on delete", fields: fields{Clients: fClient}, args: args{operation: cfg.Delete, pdt: *pdt, user: "system"}, want: false, wantErr: false,
@@ -259,7 +274,7 @@ func TestServer_mutate(t *testing.T) {
 			s := Server{
 				Clients: tt.fields.Clients,
 			}
-			got, err := s.mutate(tt.args.pdt, tt.args.operation, tt.args.user)
+			got, err := s.mutate(tt.args.pdt, nil, tt.args.operation, tt.args.user)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("mutate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -311,13 +326,171 @@ func TestServer_handle(t *testing.T) {
 				Clients: tt.fields.Clients,
 			}
 			got := s.handle(tt.args.reqPath, tt.args.req)
-			if got.Allowed != tt.want {
-				t.Errorf("handle() = %v, want %v", got, tt.want)
+			if got.response.Allowed != tt.want {
+				t.Errorf("handle() = %v, want %v", got.response, tt.want)
 			}
 		})
 	}
 }
 
+func Test_admissionDecision(t *testing.T) {
+	pdt := createProduct("sample-ns", "sample-prd", "apple")
+	skipValidate := pdt.DeepCopy()
+	skipValidate.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+
+	assert.Equal(t, metrics.Allowed, admissionDecision(cfg.ValidateURL, *pdt, nil))
+	assert.Equal(t, metrics.Skipped, admissionDecision(cfg.ValidateURL, *skipValidate, nil))
+	assert.Equal(t, metrics.Allowed, admissionDecision(cfg.MutateURL, *skipValidate, nil))
+
+	// the opt out is only honored on update when the old product already had it
+	assert.Equal(t, metrics.Allowed, admissionDecision(cfg.ValidateURL, *skipValidate, pdt))
+	assert.Equal(t, metrics.Skipped, admissionDecision(cfg.ValidateURL, *skipValidate, skipValidate))
+}
+
+func TestServer_validate_optOutOnUpdate(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	user := authenticationv1.UserInfo{Username: "testuser"}
+
+	oldPdt := createProduct("sample-ns", "sample-prd", "apple")
+	changed := oldPdt.DeepCopy()
+	changed.Spec.Brand = "samsung"
+
+	_, err := s.validate(*changed, oldPdt, cfg.Update, user)
+	assert.Error(t, err, "immutable brand change must be denied")
+
+	// setting the opt out together with the change does not skip the checks
+	optOut := changed.DeepCopy()
+	optOut.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+
+	_, err = s.validate(*optOut, oldPdt, cfg.Update, user)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "spec.brand is immutable")
+	}
+
+	// products which already opted out keep skipping the checks
+	oldOptOut := oldPdt.DeepCopy()
+	oldOptOut.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+
+	_, err = s.validate(*optOut, oldOptOut, cfg.Update, user)
+	assert.NoError(t, err)
+}
+
+func TestServer_validate_optOutProtection(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	user := authenticationv1.UserInfo{Username: "testuser"}
+
+	protected := createProduct("sample-ns", "sample-prd", "apple")
+	protected.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+	protected.Annotations[cfg.DeleteProtectAnnotation] = "true"
+
+	// the opt out does not allow deleting a protected product
+	_, err := s.validate(*protected, nil, cfg.Delete, user)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is protected from deletion")
+	}
+
+	// nor removing the protection with an update
+	unprotected := protected.DeepCopy()
+	delete(unprotected.Annotations, cfg.DeleteProtectAnnotation)
+
+	_, err = s.validate(*unprotected, protected, cfg.Update, user)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the protection can not be removed")
+	}
+
+	// other checks are still skipped
+	changed := protected.DeepCopy()
+	changed.Spec.Brand = "samsung"
+
+	_, err = s.validate(*changed, protected, cfg.Update, user)
+	assert.NoError(t, err)
+
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+	_, err = s.validate(*protected, nil, cfg.Delete, admin)
+	assert.NoError(t, err)
+}
+
+func TestServer_validate_optOutStamps(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	user := authenticationv1.UserInfo{Username: "testuser"}
+
+	forged := createProduct("sample-ns", "sample-prd", "apple")
+	forged.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+	forged.Annotations[pdtv1.ProductAnnotationWebhookMutateKey] = "false"
+	forged.Annotations[cfg.CreatedByAnnotation] = "someone-else"
+	forged.Annotations[cfg.CreatedAtAnnotation] = "2019-01-01T00:00:00Z"
+
+	// the opt out does not allow forged stamps on create
+	_, err := s.validate(*forged, nil, cfg.Create, user)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not match user testuser")
+		assert.Contains(t, err.Error(), "can not be set when the mutation is skipped")
+	}
+
+	// nor changing them on the update of a product which already opted out
+	oldPdt := createProduct("sample-ns", "sample-prd", "apple")
+	oldPdt.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+	oldPdt.Annotations[pdtv1.ProductAnnotationWebhookMutateKey] = "false"
+
+	_, err = s.validate(*forged, oldPdt, cfg.Update, user)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "can not be changed")
+	}
+
+	_, err = s.validate(*oldPdt, oldPdt, cfg.Update, user)
+	assert.NoError(t, err)
+}
+
+func TestServer_validate_protectionWarnNamespace(t *testing.T) {
+	_ = cc.LoadFixture(cc.FixtureDir)
+
+	viper.Set("app.enforcement.namespaces", map[string]string{"staging": "warn", "sandbox": "audit"})
+	defer viper.Set("app.enforcement.namespaces", nil)
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	user := authenticationv1.UserInfo{Username: "testuser"}
+
+	for _, ns := range []string{"staging", "sandbox"} {
+		protected := createProduct(ns, "sample-prd", "apple")
+		protected.Annotations[cfg.DeleteProtectAnnotation] = "true"
+
+		optOut := protected.DeepCopy()
+		optOut.Annotations[pdtv1.ProductAnnotationWebhookValidateKey] = "false"
+
+		// the namespace mode does not downgrade the deletion protection
+		for _, pdt := range []*pdtv1.Product{protected, optOut} {
+			_, err := s.validate(*pdt, nil, cfg.Delete, user)
+			if assert.Error(t, err, ns) {
+				assert.Contains(t, err.Error(), "is protected from deletion")
+			}
+		}
+	}
+}
+
 func Test_handleError(t *testing.T) {
 	handleError(httptest.NewRecorder(), fmt.Errorf("test"), 400)
 }
+
+func TestServer_Serve_requestSize(t *testing.T) {
+	pdt := createProduct("sample-ns", "sample-prd", "apple")
+	body, _ := json.Marshal(createAdmissionReview(pdt, "testuser", cfg.Create))
+
+	// a chunked request has no content length
+	request, _ := http.NewRequest("POST", "/size-test", bytes.NewReader(body))
+	request.ContentLength = -1
+
+	s := Server{Clients: ccFake.NewEstoreFakeClientForConfig(nil, nil)}
+	s.Serve(httptest.NewRecorder(), request)
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Contains(t, recorder.Body.String(), fmt.Sprintf(
+		`estore_product_webhook_admission_request_size_bytes_sum{path="/size-test"} %d`, len(body)))
+}